@@ -0,0 +1,435 @@
+package checker
+
+import (
+	"bytes"
+	_ "embed" // embed action_catalog.json
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// access levels of actions. (ref: https://docs.aws.amazon.com/service-authorization/latest/reference/reference_policies_actions-resources-contextkeys.html)
+const (
+	AccessLevelList                  = "List"
+	AccessLevelRead                  = "Read"
+	AccessLevelWrite                 = "Write"
+	AccessLevelPermissionsManagement = "Permissions management"
+	AccessLevelTagging               = "Tagging"
+)
+
+// uncataloguedActionSuffix is added to the wildcard actions of the services not in the catalog in the expanded actions.
+const uncataloguedActionSuffix = " (not in catalog)"
+
+var accessLevels = []string{
+	AccessLevelList,
+	AccessLevelRead,
+	AccessLevelWrite,
+	AccessLevelPermissionsManagement,
+	AccessLevelTagging,
+}
+
+// embeddedActionCatalog is the built-in catalog. Use `catalog` command to refresh it.
+//
+//go:embed action_catalog.json
+var embeddedActionCatalog []byte
+
+var (
+	defaultActionCatalog     *ActionCatalog
+	defaultActionCatalogOnce sync.Once
+)
+
+// ActionCatalog contains actions of AWS services with the access level and resource types.
+type ActionCatalog struct {
+	Services []ServiceActions `json:"services"`
+
+	entries  []catalogEntry          // all of the actions sorted by name
+	index    map[string]catalogEntry // key is lower-cased `<service>:<action>`
+	services map[string]bool         // value is Partial of the service
+}
+
+// ServiceActions contains actions of the service.
+type ServiceActions struct {
+	Prefix  string             `json:"prefix"` // e.g. `s3`
+	Name    string             `json:"name,omitempty"`
+	Partial bool               `json:"partial,omitempty"` // only the major actions are in the catalog
+	Actions []ActionDefinition `json:"actions"`
+}
+
+// ActionDefinition contains the access level and resource types of the action.
+type ActionDefinition struct {
+	Name          string   `json:"name"` // e.g. `GetObject`
+	AccessLevel   string   `json:"access_level"`
+	ResourceTypes []string `json:"resource_types,omitempty"`
+}
+
+type catalogEntry struct {
+	name  string // e.g. `s3:GetObject`
+	lower string
+	ActionDefinition
+}
+
+// DefaultActionCatalog returns the built-in catalog.
+func DefaultActionCatalog() *ActionCatalog {
+	defaultActionCatalogOnce.Do(func() {
+		c, err := NewActionCatalog(embeddedActionCatalog)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in action catalog: %s", err.Error()))
+		}
+		defaultActionCatalog = c
+	})
+	return defaultActionCatalog
+}
+
+// ReadActionCatalogFile reads the catalog from the file.
+// See NewActionCatalog for the supported formats.
+func ReadActionCatalogFile(file string) (*ActionCatalog, error) {
+	byt, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := NewActionCatalog(byt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return c, nil
+}
+
+// NewActionCatalog creates *ActionCatalog from JSON data.
+// Both of this catalog format and the service reference of Service Authorization Reference
+// (a service object or an array of them; ref: https://docs.aws.amazon.com/service-authorization/latest/reference/service-reference.html) are supported.
+func NewActionCatalog(data []byte) (*ActionCatalog, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("empty action catalog")
+	}
+
+	var refs []serviceReference
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, &refs); err != nil {
+			return nil, err
+		}
+		return newActionCatalogFromReferences(refs)
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, err
+	}
+	switch {
+	case keys["services"] != nil:
+		c := &ActionCatalog{}
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, err
+		}
+		c.init()
+		return c, nil
+	case keys["Actions"] != nil:
+		var ref serviceReference
+		if err := json.Unmarshal(data, &ref); err != nil {
+			return nil, err
+		}
+		return newActionCatalogFromReferences([]serviceReference{ref})
+	}
+	return nil, errors.New("unsupported action catalog format")
+}
+
+// serviceReference is a service in Service Authorization Reference.
+type serviceReference struct {
+	Name    string `json:"Name"`
+	Actions []struct {
+		Name        string `json:"Name"`
+		Annotations struct {
+			Properties struct {
+				IsList                 bool `json:"IsList"`
+				IsPermissionManagement bool `json:"IsPermissionManagement"`
+				IsTaggingOnly          bool `json:"IsTaggingOnly"`
+				IsWrite                bool `json:"IsWrite"`
+			} `json:"Properties"`
+		} `json:"Annotations"`
+		Resources []struct {
+			Name string `json:"Name"`
+		} `json:"Resources"`
+	} `json:"Actions"`
+}
+
+func newActionCatalogFromReferences(refs []serviceReference) (*ActionCatalog, error) {
+	c := &ActionCatalog{}
+	for _, ref := range refs {
+		if ref.Name == "" {
+			return nil, errors.New("service name is empty in the service reference")
+		}
+
+		svc := ServiceActions{
+			Prefix:  strings.ToLower(ref.Name),
+			Actions: make([]ActionDefinition, 0, len(ref.Actions)),
+		}
+		for _, a := range ref.Actions {
+			prop := a.Annotations.Properties
+			level := AccessLevelRead
+			switch {
+			case prop.IsPermissionManagement:
+				level = AccessLevelPermissionsManagement
+			case prop.IsTaggingOnly:
+				level = AccessLevelTagging
+			case prop.IsWrite:
+				level = AccessLevelWrite
+			case prop.IsList:
+				level = AccessLevelList
+			}
+
+			def := ActionDefinition{
+				Name:        a.Name,
+				AccessLevel: level,
+			}
+			for _, r := range a.Resources {
+				def.ResourceTypes = append(def.ResourceTypes, r.Name)
+			}
+			svc.Actions = append(svc.Actions, def)
+		}
+		c.Services = append(c.Services, svc)
+	}
+	c.init()
+	return c, nil
+}
+
+// init sorts the services and actions and builds the index.
+func (c *ActionCatalog) init() {
+	sort.Slice(c.Services, func(i, j int) bool {
+		return c.Services[i].Prefix < c.Services[j].Prefix
+	})
+
+	c.entries = nil
+	c.index = make(map[string]catalogEntry)
+	c.services = make(map[string]bool, len(c.Services))
+	for i := range c.Services {
+		svc := &c.Services[i]
+		svc.Prefix = strings.ToLower(svc.Prefix)
+		sort.Slice(svc.Actions, func(i, j int) bool {
+			return strings.ToLower(svc.Actions[i].Name) < strings.ToLower(svc.Actions[j].Name)
+		})
+
+		c.services[svc.Prefix] = svc.Partial
+		for _, a := range svc.Actions {
+			e := catalogEntry{
+				name:             svc.Prefix + ":" + a.Name,
+				ActionDefinition: a,
+			}
+			e.lower = strings.ToLower(e.name)
+			c.entries = append(c.entries, e)
+			c.index[e.lower] = e
+		}
+	}
+}
+
+// Merge returns a new catalog which has the services of the other catalog.
+// The actions of the same service are replaced by the other catalog.
+// The services of Service Authorization Reference are not partial, since they contain all of the actions.
+func (c *ActionCatalog) Merge(other *ActionCatalog) *ActionCatalog {
+	m := make(map[string]ServiceActions, len(c.Services)+len(other.Services))
+	for _, svc := range c.Services {
+		m[svc.Prefix] = svc
+	}
+	for _, svc := range other.Services {
+		if svc.Name == "" {
+			// service reference does not have the full name.
+			svc.Name = m[svc.Prefix].Name
+		}
+		m[svc.Prefix] = svc
+	}
+
+	result := &ActionCatalog{
+		Services: make([]ServiceActions, 0, len(m)),
+	}
+	for _, svc := range m {
+		result.Services = append(result.Services, svc)
+	}
+	result.init()
+	return result
+}
+
+// Size returns the number of the actions.
+func (c *ActionCatalog) Size() int {
+	return len(c.entries)
+}
+
+// HasService checks if the catalog contains the service. (e.g. `s3`)
+func (c *ActionCatalog) HasService(prefix string) bool {
+	_, ok := c.services[strings.ToLower(prefix)]
+	return ok
+}
+
+// IsPartialService checks if the catalog contains only a part of the actions of the service.
+// The actions not in the catalog may exist in the partial service.
+func (c *ActionCatalog) IsPartialService(prefix string) bool {
+	return c.services[strings.ToLower(prefix)]
+}
+
+// GetAction returns the definition of the action. (e.g. `s3:GetObject`)
+func (c *ActionCatalog) GetAction(action string) (ActionDefinition, bool) {
+	e, ok := c.index[strings.ToLower(action)]
+	return e.ActionDefinition, ok
+}
+
+// ExpandAction returns the actions matched by the pattern. (e.g. `s3:Get*` returns `s3:GetObject`, `s3:GetBucketPolicy`, ...)
+func (c *ActionCatalog) ExpandAction(pattern string) []string {
+	pattern = strings.ToLower(pattern)
+
+	var result []string
+	for _, e := range c.entries {
+		if matchWildcard(pattern, e.lower) {
+			result = append(result, e.name)
+		}
+	}
+	return result
+}
+
+// grantedActions returns the actions granted by the Allow statement and not denied by the Deny statements.
+// When levels are given, only the actions with the access levels are returned.
+func (c *ActionCatalog) grantedActions(s Statement, denies []Statement, levels []string) []catalogEntry {
+	var result []catalogEntry
+	for _, e := range c.entries {
+		if len(levels) != 0 && !containsAccessLevel(levels, e.AccessLevel) {
+			continue
+		}
+		if s.coversAction(e.name) && !isDeniedAction(s, e.name, denies) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// expandStatements returns the actions granted by wildcard actions or NotAction in the Allow statements.
+// excludes are handled as same as Deny statements in the policy.
+// Wildcard actions of the services not in the catalog are returned as it is with the suffix. (e.g. `glue:* (not in catalog)`)
+func (c *ActionCatalog) expandStatements(statements, excludes []Statement, levels []string) []string {
+	denies := append(getDenyStatements(statements), excludes...)
+
+	var result []string
+	for _, s := range statements {
+		if !s.IsAllow() || !hasWildcardAction(s) {
+			continue
+		}
+		for _, e := range c.grantedActions(s, denies, levels) {
+			result = append(result, e.name)
+		}
+		// the actions of the services not in the catalog cannot be expanded.
+		for _, a := range s.Action {
+			if hasWildcard(a) && c.getUncataloguedService(a) != "" {
+				result = append(result, a+uncataloguedActionSuffix)
+			}
+		}
+	}
+	return uniqueAndSort(result)
+}
+
+// WriteFile saves the catalog to the file with a line per action.
+func (c *ActionCatalog) WriteFile(file string) error {
+	var buf bytes.Buffer
+	buf.WriteString("{\n  \"services\": [\n")
+	for i, svc := range c.Services {
+		prefix, _ := json.Marshal(svc.Prefix)
+		name, _ := json.Marshal(svc.Name)
+		fmt.Fprintf(&buf, "    {\n      \"prefix\": %s,\n      \"name\": %s,\n", prefix, name)
+		if svc.Partial {
+			buf.WriteString("      \"partial\": true,\n")
+		}
+		buf.WriteString("      \"actions\": [\n")
+		for j, a := range svc.Actions {
+			byt, err := json.Marshal(a)
+			if err != nil {
+				return err
+			}
+			buf.WriteString("        ")
+			buf.Write(byt)
+			if j < len(svc.Actions)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("      ]\n    }")
+		if i < len(c.Services)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("  ]\n}\n")
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// RefreshActionCatalog updates the services of the base catalog by the input files and saves it to the output file.
+// When base is empty, the built-in catalog is used.
+func RefreshActionCatalog(base, output string, inputs ...string) (*ActionCatalog, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("input file is empty")
+	}
+
+	c := DefaultActionCatalog()
+	if base != "" {
+		var err error
+		if c, err = ReadActionCatalogFile(base); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range inputs {
+		other, err := ReadActionCatalogFile(file)
+		if err != nil {
+			return nil, err
+		}
+		c = c.Merge(other)
+	}
+
+	if err := checkIsDir(output); err != nil {
+		return nil, err
+	}
+	return c, c.WriteFile(output)
+}
+
+// getUncataloguedService returns the service of the action when the service is not in the catalog. (e.g. `glue` of `glue:*`)
+// It returns empty string for the actions of any service. (e.g. `*`, `*:Get*`)
+func (c *ActionCatalog) getUncataloguedService(action string) string {
+	parts := strings.SplitN(action, ":", 2)
+	if len(parts) != 2 || hasWildcard(parts[0]) || c.HasService(parts[0]) {
+		return ""
+	}
+	return strings.ToLower(parts[0])
+}
+
+// hasWildcardAction checks if the statement grants actions by wildcard or NotAction.
+func hasWildcardAction(s Statement) bool {
+	if len(s.NotAction) != 0 {
+		return true
+	}
+	for _, a := range s.Action {
+		if hasWildcard(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAccessLevel converts the access level in the filtering rule into the catalog format.
+// (e.g. `permissions-management` to `Permissions management`)
+func normalizeAccessLevel(level string) (string, error) {
+	v := strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSpace(level))
+	for _, l := range accessLevels {
+		if strings.EqualFold(v, l) {
+			return l, nil
+		}
+	}
+	return "", fmt.Errorf("unknown access level: '%s'", level)
+}
+
+func containsAccessLevel(levels []string, level string) bool {
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		levels []string
+		want   string
+	}{
+		{
+			name: "wildcard action",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"sts:Get*","Resource":"*"}]}`,
+			want: "sts:GetAccessKeyInfo sts:GetCallerIdentity sts:GetFederationToken sts:GetSessionToken",
+		},
+		{
+			name: "actions without wildcard are not expanded",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":["s3:GetObject","glue:GetTable"],"Resource":"*"}]}`,
+			want: "",
+		},
+		{
+			name: "duplicated actions",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"sts:GetCallerIdentity","Resource":"*"},
+				{"Effect":"Allow","Action":["sts:GetC*","sts:*CallerIdentity"],"Resource":"*"}
+			]}`,
+			want: "sts:GetCallerIdentity",
+		},
+		{
+			name: "denied actions",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"sts:Get*","Resource":"*"},
+				{"Effect":"Deny","Action":["sts:GetFederationToken","sts:GetSessionToken"],"Resource":"*"}
+			]}`,
+			want: "sts:GetAccessKeyInfo sts:GetCallerIdentity",
+		},
+		{
+			name: "service not in catalog",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":["glue:*","sts:GetC*"],"Resource":"*"}]}`,
+			want: "glue:* (not in catalog) sts:GetCallerIdentity",
+		},
+		{
+			name:   "service not in catalog with access levels",
+			doc:    `{"Statement":[{"Effect":"Allow","Action":["Glue:Get*","sts:Get*"],"Resource":"*"}]}`,
+			levels: []string{AccessLevelWrite},
+			want:   "Glue:Get* (not in catalog)",
+		},
+		{
+			name: "wildcard service",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"*:GetCallerIdentity","Resource":"*"}]}`,
+			want: "sts:GetCallerIdentity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPolicy(t, "test", tt.doc)
+			got := DefaultActionCatalog().expandStatements(p.Policy.Statement, nil, tt.levels)
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("expandStatements() = %q, want %q", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUncataloguedService(t *testing.T) {
+	tests := []struct {
+		action string
+		want   string
+	}{
+		{"glue:*", "glue"},
+		{"Glue:GetTable", "glue"},
+		{"s3:*", ""},
+		{"S3:GetObject", ""},
+		{"*", ""},
+		{"*:Get*", ""},
+		{"GetObject", ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultActionCatalog().getUncataloguedService(tt.action); got != tt.want {
+			t.Errorf("getUncataloguedService(%q) = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
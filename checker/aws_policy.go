@@ -2,6 +2,7 @@ package checker
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
 )
@@ -12,19 +13,34 @@ const (
 	entityRole  = "role"
 )
 
+// scopes of managed policies.
+const (
+	PolicyScopeAll   = "all"
+	PolicyScopeAWS   = "aws"   // AWS managed policies
+	PolicyScopeLocal = "local" // customer managed policies
+)
+
 // AwsPolicy contains aws policy data.
 type AwsPolicy struct {
-	ARN                   string
-	PolicyName            string
-	Policy                iam.PolicyDocument
-	PolicyActions         []string
-	PolicyResourceActions []ResourceAction
-
-	AttachedUsers      []string
-	AttachedGroups     []Group
-	AttachedGroupUsers []string
-	AttachedAllUsers   []string
-	AttachedRoles      []string
+	ARN                   string           `json:"arn,omitempty"`
+	PolicyName            string           `json:"policy_name"`
+	AttachmentCount       int64            `json:"attachment_count,omitempty"`
+	VersionID             string           `json:"version_id,omitempty"`
+	NonDefaultVersion     bool             `json:"non_default_version,omitempty"`
+	BroaderPermissions    []string         `json:"broader_permissions,omitempty"` // permissions not allowed by the default version
+	Policy                PolicyDocument   `json:"policy"`
+	PolicyActions         []string         `json:"policy_actions"`
+	ExpandedActions       []string         `json:"expanded_actions,omitempty"` // actions granted by wildcard actions or NotAction
+	PolicyResourceActions []ResourceAction `json:"policy_resource_actions"`
+
+	AttachedUsers      []string `json:"attached_users"`
+	AttachedGroups     []Group  `json:"attached_groups"`
+	AttachedGroupUsers []string `json:"attached_group_users"`
+	AttachedAllUsers   []string `json:"attached_all_users"`
+	AttachedRoles      []string `json:"attached_roles"`
+
+	Trust              *RoleTrust   `json:"trust,omitempty"`                // trust policy of the role for inline policy of the role
+	AttachedRoleTrusts []*RoleTrust `json:"attached_role_trusts,omitempty"` // trust policies of the attached roles for managed policy
 }
 
 func (p AwsPolicy) GetEntityAndType() (typ string, entities []string) {
@@ -40,14 +56,70 @@ func (p AwsPolicy) GetEntityAndType() (typ string, entities []string) {
 	}
 }
 
+// GetPolicyType returns `managed` or `inline`.
+func (p AwsPolicy) GetPolicyType() string {
+	if p.ARN == "" {
+		return policyTypeInline
+	}
+	return policyTypeManaged
+}
+
+// GetScope returns `aws` for AWS managed policy or `local` for customer managed policy.
+// It returns empty string for inline policy.
+func (p AwsPolicy) GetScope() string {
+	switch {
+	case p.ARN == "":
+		return ""
+	case isAWSManagedPolicy(p.ARN):
+		return PolicyScopeAWS
+	default:
+		return PolicyScopeLocal
+	}
+}
+
+// isAWSManagedPolicy checks if the ARN is AWS managed policy. (e.g. `arn:aws:iam::aws:policy/ReadOnlyAccess`)
+func isAWSManagedPolicy(arn string) bool {
+	return strings.Contains(arn, ":iam::aws:policy/")
+}
+
+// isPolicyInScope checks if the managed policy is in the scope.
+func isPolicyInScope(arn, scope string) bool {
+	switch scope {
+	case PolicyScopeAWS:
+		return isAWSManagedPolicy(arn)
+	case PolicyScopeLocal:
+		return !isAWSManagedPolicy(arn)
+	}
+	return true
+}
+
+// GetEntities returns all of the attached entities with the type. (e.g. `user/foo`)
+func (p AwsPolicy) GetEntities() []string {
+	result := make([]string, 0, len(p.AttachedUsers)+len(p.AttachedGroups)+len(p.AttachedRoles))
+	for _, v := range p.AttachedUsers {
+		result = append(result, entityUser+"/"+v)
+	}
+	for _, v := range p.AttachedGroups {
+		result = append(result, entityGroup+"/"+v.Name)
+	}
+	for _, v := range p.AttachedRoles {
+		result = append(result, entityRole+"/"+v)
+	}
+	return result
+}
+
 // SetPolicy sets resources and actions from PolicyDcoument.
-func (p *AwsPolicy) SetPolicy(pd iam.PolicyDocument) {
+func (p *AwsPolicy) SetPolicy(pd PolicyDocument) {
 	p.Policy = pd
 	for _, s := range pd.Statement {
 		p.PolicyActions = append(p.PolicyActions, s.Action...)
 		p.PolicyResourceActions = append(p.PolicyResourceActions, ResourceAction{
-			Resources: s.Resource,
-			Actions:   s.Action,
+			Effect:       s.Effect,
+			Resources:    s.Resource,
+			Actions:      s.Action,
+			NotResources: s.NotResource,
+			NotActions:   s.NotAction,
+			Conditions:   s.Condition,
 		})
 	}
 }
@@ -72,8 +144,8 @@ func (p *AwsPolicy) SetEntityList(list []iam.PolicyEntity) {
 
 // Group contains group name and users.
 type Group struct {
-	Name  string
-	Users []string
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
 }
 
 func (g Group) String() string {
@@ -91,8 +163,12 @@ func GetGroupNames(list []Group) []string {
 
 // ResourceAction contains Action and Resource list.
 type ResourceAction struct {
-	Actions   []string `json:"actions"`
-	Resources []string `json:"resources"`
+	Effect       string    `json:"effect"`
+	Actions      []string  `json:"actions,omitempty"`
+	NotActions   []string  `json:"not_actions,omitempty"`
+	Resources    []string  `json:"resources,omitempty"`
+	NotResources []string  `json:"not_resources,omitempty"`
+	Conditions   Condition `json:"conditions,omitempty"`
 }
 
 // GetResourceAndAction returns resource and action of policy.
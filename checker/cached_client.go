@@ -0,0 +1,226 @@
+package checker
+
+import (
+	"strings"
+	"sync"
+
+	SDK "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// CachedClient is IAMClient caching the results of other IAMClient.
+// It's used to run multiple checks from a single data fetch.
+// Errors are not cached, so the failed calls are retried in the next checks.
+type CachedClient struct {
+	client IAMClient
+
+	mu    sync.Mutex
+	cache map[string]*cachedResult
+}
+
+type cachedResult struct {
+	mu    sync.Mutex
+	done  bool
+	value interface{}
+}
+
+// NewCachedClient creates *CachedClient from IAMClient.
+func NewCachedClient(cli IAMClient) *CachedClient {
+	return &CachedClient{
+		client: cli,
+		cache:  make(map[string]*cachedResult),
+	}
+}
+
+// get returns the cached value of the key, or calls fn and caches the value.
+// Concurrent calls for the same key wait for the first call.
+func (c *CachedClient) get(key string, fn func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	r, ok := c.cache[key]
+	if !ok {
+		r = &cachedResult{}
+		c.cache[key] = r
+	}
+	c.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.done {
+		return r.value, nil
+	}
+
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	r.value = v
+	r.done = true
+	return v, nil
+}
+
+func cacheKey(operation string, params ...string) string {
+	return operation + "/" + strings.Join(params, "/")
+}
+
+// ListPolicies returns managed policies in the scope.
+// All of the managed policies are fetched once, and filtered by the scope.
+func (c *CachedClient) ListPolicies(scope string, onlyAttached bool) ([]iam.Policy, error) {
+	v, err := c.get(cacheKey("ListPolicies"), func() (interface{}, error) {
+		return c.client.ListPolicies(PolicyScopeAll, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := v.([]iam.Policy)
+	list := make([]iam.Policy, 0, len(all))
+	for _, p := range all {
+		if onlyAttached && p.AttachmentCount == 0 {
+			continue
+		}
+		if !isPolicyInScope(p.ARN, scope) {
+			continue
+		}
+		list = append(list, p)
+	}
+	return list, nil
+}
+
+// GetPolicyVersion returns the version of managed policy.
+func (c *CachedClient) GetPolicyVersion(arn, versionID string) (*SDK.PolicyVersion, error) {
+	v, err := c.get(cacheKey("GetPolicyVersion", arn, versionID), func() (interface{}, error) {
+		return c.client.GetPolicyVersion(arn, versionID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*SDK.PolicyVersion), nil
+}
+
+// ListPolicyVersions returns all of the versions of the policy.
+func (c *CachedClient) ListPolicyVersions(arn string) ([]*SDK.PolicyVersion, error) {
+	v, err := c.get(cacheKey("ListPolicyVersions", arn), func() (interface{}, error) {
+		return c.client.ListPolicyVersions(arn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*SDK.PolicyVersion), nil
+}
+
+// ListEntitiesForPolicy returns User/Group/Role attached to the policy.
+func (c *CachedClient) ListEntitiesForPolicy(arn string) ([]iam.PolicyEntity, error) {
+	v, err := c.get(cacheKey("ListEntitiesForPolicy", arn), func() (interface{}, error) {
+		return c.client.ListEntitiesForPolicy(arn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]iam.PolicyEntity), nil
+}
+
+// GetGroup returns the group and the users in the group.
+func (c *CachedClient) GetGroup(groupName string) (*SDK.GetGroupOutput, error) {
+	v, err := c.get(cacheKey("GetGroup", groupName), func() (interface{}, error) {
+		return c.client.GetGroup(groupName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*SDK.GetGroupOutput), nil
+}
+
+// ListUsers returns all of the users.
+func (c *CachedClient) ListUsers() ([]iam.User, error) {
+	v, err := c.get(cacheKey("ListUsers"), func() (interface{}, error) {
+		return c.client.ListUsers()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]iam.User), nil
+}
+
+// ListUserPolicies returns inline policy names of the user.
+func (c *CachedClient) ListUserPolicies(userName string) ([]string, error) {
+	return c.getStrings(cacheKey("ListUserPolicies", userName), func() ([]string, error) {
+		return c.client.ListUserPolicies(userName)
+	})
+}
+
+// GetUserPolicyDocument returns the inline policy document of the user.
+func (c *CachedClient) GetUserPolicyDocument(userName, policyName string) (*PolicyDocument, error) {
+	return c.getDocument(cacheKey("GetUserPolicyDocument", userName, policyName), func() (*PolicyDocument, error) {
+		return c.client.GetUserPolicyDocument(userName, policyName)
+	})
+}
+
+// ListGroups returns all of the groups.
+func (c *CachedClient) ListGroups() ([]iam.Group, error) {
+	v, err := c.get(cacheKey("ListGroups"), func() (interface{}, error) {
+		return c.client.ListGroups()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]iam.Group), nil
+}
+
+// ListGroupPolicies returns inline policy names of the group.
+func (c *CachedClient) ListGroupPolicies(groupName string) ([]string, error) {
+	return c.getStrings(cacheKey("ListGroupPolicies", groupName), func() ([]string, error) {
+		return c.client.ListGroupPolicies(groupName)
+	})
+}
+
+// GetGroupPolicyDocument returns the inline policy document of the group.
+func (c *CachedClient) GetGroupPolicyDocument(groupName, policyName string) (*PolicyDocument, error) {
+	return c.getDocument(cacheKey("GetGroupPolicyDocument", groupName, policyName), func() (*PolicyDocument, error) {
+		return c.client.GetGroupPolicyDocument(groupName, policyName)
+	})
+}
+
+// ListRoles returns all of the roles.
+func (c *CachedClient) ListRoles() ([]iam.Role, error) {
+	v, err := c.get(cacheKey("ListRoles"), func() (interface{}, error) {
+		return c.client.ListRoles()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]iam.Role), nil
+}
+
+// ListRolePolicies returns inline policy names of the role.
+func (c *CachedClient) ListRolePolicies(roleName string) ([]string, error) {
+	return c.getStrings(cacheKey("ListRolePolicies", roleName), func() ([]string, error) {
+		return c.client.ListRolePolicies(roleName)
+	})
+}
+
+// GetRolePolicyDocument returns the inline policy document of the role.
+func (c *CachedClient) GetRolePolicyDocument(roleName, policyName string) (*PolicyDocument, error) {
+	return c.getDocument(cacheKey("GetRolePolicyDocument", roleName, policyName), func() (*PolicyDocument, error) {
+		return c.client.GetRolePolicyDocument(roleName, policyName)
+	})
+}
+
+func (c *CachedClient) getStrings(key string, fn func() ([]string, error)) ([]string, error) {
+	v, err := c.get(key, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+func (c *CachedClient) getDocument(key string, fn func() (*PolicyDocument, error)) (*PolicyDocument, error) {
+	v, err := c.get(key, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*PolicyDocument), nil
+}
@@ -0,0 +1,359 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// check types in the config file.
+const (
+	CheckTypePolicy       = "policy"
+	CheckTypeInlinePolicy = "inline_policy"
+	CheckTypeAudit        = "audit"
+	CheckTypeEscalation   = "escalation"
+	CheckTypeTrust        = "trust"
+	CheckTypePrincipal    = "principal"
+	CheckTypeLint         = "lint"
+)
+
+// CheckFile is YAML config file of named checks.
+// All of the checks are run from a single data fetch.
+//
+//	input: details.json
+//	checks:
+//	  - name: s3-writers
+//	    filter: service:s3 AND access:write
+//	    exclude:
+//	      entity: [role/AWSServiceRoleFor*]
+//	    scope: local
+//	    output: s3-writers.csv
+type CheckFile struct {
+	Input         string  `yaml:"input"`          // JSON file of `aws iam get-account-authorization-details` (default: AWS API)
+	Parallel      int     `yaml:"parallel"`       // number of concurrent API calls (default: 1)
+	MaxRetry      int     `yaml:"max_retry"`      // max retry count on throttling error (default: 5)
+	Catalog       string  `yaml:"catalog"`        // JSON file of the action catalog (default: the built-in catalog)
+	CatalogStrict bool    `yaml:"catalog_strict"` // return error for unknown actions of the services in the catalog in the action rules
+	Strict        bool    `yaml:"strict"`         // return error when any item is skipped by errors
+	Suppression   string  `yaml:"suppression"`    // YAML file of the suppressions used in all of the checks
+	Checks        []Check `yaml:"checks"`
+
+	FailOnMatch bool   `yaml:"fail_on_match"` // default of fail_on_match in the checks
+	FailOn      string `yaml:"fail_on"`       // default of fail_on in the checks with severity (audit, trust and lint)
+
+	LogLevel  string `yaml:"log_level"`  // debug, info, warn or error (default: info)
+	LogFormat string `yaml:"log_format"` // text or json (default: text)
+	Logger    Logger `yaml:"-"`          // custom logger used instead of the default logger
+}
+
+// Check is a named check in the config file. The fields are same as the options of the command.
+type Check struct {
+	Name        string `yaml:"name"`
+	Type        string `yaml:"type"`   // policy, inline_policy, audit, escalation, trust, principal or lint (default: policy)
+	Output      string `yaml:"output"` // (default: `<name>.csv`)
+	Format      string `yaml:"format"`
+	ErrorOutput string `yaml:"error_output"`  // output file of the skipped items by errors (default: `<name>.errors.csv`)
+	FailOnMatch bool   `yaml:"fail_on_match"` // return error when any finding exists
+	FailOn      string `yaml:"fail_on"`       // return error when any finding is at or above the severity (e.g. `high`)
+
+	Resource          StringList     `yaml:"resource"`
+	Action            StringList     `yaml:"action"`
+	Service           StringList     `yaml:"service"`
+	AccessLevel       StringList     `yaml:"access_level"`
+	Filter            string         `yaml:"filter"`
+	All               bool           `yaml:"all"`
+	Scope             string         `yaml:"scope"`
+	IncludeUnattached bool           `yaml:"include_unattached"`
+	AllVersions       bool           `yaml:"all_versions"`
+	Wildcard          bool           `yaml:"wildcard"`
+	Unconditional     bool           `yaml:"unconditional"`
+	MissingCondition  StringList     `yaml:"missing_condition"`
+	Rule              StringList     `yaml:"rule"`
+	TrustedAccount    StringList     `yaml:"trusted_account"`
+	Exclude           CheckExclusion `yaml:"exclude"`
+}
+
+// CheckExclusion is exclusion rules of the check.
+type CheckExclusion struct {
+	Resource StringList `yaml:"resource"`
+	Action   StringList `yaml:"action"`
+	Entity   StringList `yaml:"entity"`
+	Policy   StringList `yaml:"policy"`
+}
+
+// StringList is a list of rules written as YAML list or space separated string.
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	*l = toStringList(s)
+	return nil
+}
+
+func (l StringList) String() string {
+	return strings.Join(l, defaultSeparator)
+}
+
+// ReadCheckFile reads and validates YAML config file of the checks.
+func ReadCheckFile(file string) (*CheckFile, error) {
+	byt, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := CheckFile{}
+	if err := yaml.UnmarshalStrict(byt, &f); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as config file: %s", file, err.Error())
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate validates the checks have unique names and output files, and valid rules.
+func (f CheckFile) Validate() error {
+	if len(f.Checks) == 0 {
+		return errors.New("config file does not contain any checks")
+	}
+	if f.FailOn != "" {
+		if _, err := ParseSeverity(f.FailOn); err != nil {
+			return err
+		}
+	}
+
+	names := make(map[string]struct{}, len(f.Checks))
+	outputs := make(map[string]string, len(f.Checks))
+	for _, ch := range f.Checks {
+		if ch.Name == "" {
+			return errors.New("check name is empty")
+		}
+		if _, ok := names[ch.Name]; ok {
+			return fmt.Errorf("duplicate check name: '%s'", ch.Name)
+		}
+		names[ch.Name] = struct{}{}
+
+		if err := validateCheckType(ch.GetType()); err != nil {
+			return fmt.Errorf("check '%s': %s", ch.Name, err.Error())
+		}
+		if ch.FailOn != "" && !hasSeverity(ch.GetType()) {
+			return fmt.Errorf("check '%s': fail_on cannot be used in '%s' check without severity", ch.Name, ch.GetType())
+		}
+
+		conf := ch.ToConfig(f)
+		if err := conf.Validate(); err != nil {
+			return fmt.Errorf("check '%s': %s", ch.Name, err.Error())
+		}
+
+		out := conf.GetOutputFile()
+		if name, ok := outputs[out]; ok {
+			return fmt.Errorf("check '%s': output file '%s' is used in check '%s'", ch.Name, out, name)
+		}
+		outputs[out] = ch.Name
+	}
+	return nil
+}
+
+// Select returns the config file which contains only the given checks.
+func (f CheckFile) Select(names []string) (CheckFile, error) {
+	if len(names) == 0 {
+		return f, nil
+	}
+
+	m := make(map[string]Check, len(f.Checks))
+	for _, ch := range f.Checks {
+		m[ch.Name] = ch
+	}
+
+	result := f
+	result.Checks = make([]Check, 0, len(names))
+	for _, name := range names {
+		ch, ok := m[name]
+		if !ok {
+			return CheckFile{}, fmt.Errorf("unknown check: '%s'", name)
+		}
+		result.Checks = append(result.Checks, ch)
+	}
+	return result, nil
+}
+
+// Run runs all of the checks. IAM data is fetched from the input file or AWS API only once.
+// It returns the summaries of the finished checks.
+// When some of the checks fail, other checks are still run and the error contains the failed checks.
+// When no check fails, it returns *FindingsError for the findings exceeding the threshold,
+// or *PartialDataError in strict mode when any item is skipped.
+func (f CheckFile) Run() ([]CheckSummary, error) {
+	cli, err := newIAMClient(Config{InputFile: f.Input})
+	if err != nil {
+		return nil, err
+	}
+	return f.RunWithClient(cli)
+}
+
+// RunWithClient runs all of the checks with IAMClient.
+// The results of IAMClient are cached and shared by the checks.
+func (f CheckFile) RunWithClient(cli IAMClient) ([]CheckSummary, error) {
+	if cli == nil {
+		return nil, errors.New("IAMClient is nil")
+	}
+
+	cached := NewCachedClient(cli)
+	var summaries []CheckSummary
+	var failed []string
+	var findings []CheckSummary
+	var skipped []FetchError
+	for _, ch := range f.Checks {
+		c, err := NewWithClient(ch.ToConfig(f), cached)
+		if err != nil {
+			return summaries, fmt.Errorf("check '%s': %s", ch.Name, err.Error())
+		}
+		c.checkName = ch.Name
+
+		c.loggingInfo("invoking `RunCheck` name:[%s] type:[%s] output:[%s] ...", ch.Name, ch.GetType(), c.config.GetOutputFile())
+		err = c.runCheck(ch.GetType())
+		if s := c.Summary(); s != nil {
+			summaries = append(summaries, *s)
+		}
+
+		var findingsErr *FindingsError
+		var partialErr *PartialDataError
+		switch {
+		case err == nil:
+		case errors.As(err, &findingsErr):
+			findings = append(findings, findingsErr.Summaries...)
+		case errors.As(err, &partialErr):
+			skipped = append(skipped, partialErr.Errors...)
+		default:
+			c.loggingError("Func:[RunCheck] Error:[%s], Name:[%s]", err, ch.Name)
+			failed = append(failed, ch.Name)
+		}
+	}
+
+	switch {
+	case len(failed) != 0:
+		return summaries, fmt.Errorf("failed checks: %s", strings.Join(failed, ", "))
+	case len(findings) != 0:
+		return summaries, &FindingsError{Summaries: findings}
+	case len(skipped) != 0:
+		return summaries, &PartialDataError{Errors: skipped}
+	}
+	return summaries, nil
+}
+
+// GetType gets the check type.
+func (ch Check) GetType() string {
+	if ch.Type == "" {
+		return CheckTypePolicy
+	}
+	return strings.ToLower(ch.Type)
+}
+
+// GetOutputFile gets output file name. (default: `<name>.csv`)
+func (ch Check) GetOutputFile() string {
+	if ch.Output != "" {
+		return ch.Output
+	}
+	return ch.Name + ".csv"
+}
+
+// ToConfig converts the check into Config with the common settings of the config file.
+func (ch Check) ToConfig(f CheckFile) Config {
+	conf := Config{
+		OutputFile:        ch.GetOutputFile(),
+		OutputFormat:      ch.Format,
+		ErrorOutputFile:   ch.ErrorOutput,
+		Strict:            f.Strict,
+		SuppressionFile:   f.Suppression,
+		FailOnMatch:       ch.FailOnMatch || f.FailOnMatch,
+		InputFile:         f.Input,
+		Parallel:          f.Parallel,
+		MaxRetry:          f.MaxRetry,
+		ActionCatalogFile: f.Catalog,
+		CatalogStrict:     f.CatalogStrict,
+		LogLevel:          f.LogLevel,
+		LogFormat:         f.LogFormat,
+		Logger:            f.Logger,
+		ExcludeEntity:     ch.Exclude.Entity.String(),
+		ExcludePolicy:     ch.Exclude.Policy.String(),
+	}
+
+	if hasSeverity(ch.GetType()) {
+		conf.FailOn = ch.FailOn
+		if conf.FailOn == "" {
+			conf.FailOn = f.FailOn
+		}
+	}
+
+	switch ch.GetType() {
+	case CheckTypePolicy, CheckTypeInlinePolicy:
+		conf.TargetResource = ch.Resource.String()
+		conf.TargetAction = ch.Action.String()
+		conf.TargetActionService = ch.Service.String()
+		conf.AccessLevel = ch.AccessLevel.String()
+		conf.Filter = ch.Filter
+		conf.ShowAllPolicy = ch.All
+		conf.PolicyScope = ch.Scope
+		conf.IncludeUnattached = ch.IncludeUnattached
+		conf.AllVersions = ch.AllVersions
+		conf.MatchStatementWildcard = ch.Wildcard
+		conf.OnlyUnconditional = ch.Unconditional
+		conf.MissingCondition = ch.MissingCondition.String()
+		conf.TrustedAccount = ch.TrustedAccount.String()
+		conf.ExcludeResource = ch.Exclude.Resource.String()
+		conf.ExcludeAction = ch.Exclude.Action.String()
+	default:
+		conf.ShowAllPolicy = true
+		conf.AuditRule = ch.Rule.String()
+		conf.TrustedAccount = ch.TrustedAccount.String()
+	}
+	return conf
+}
+
+// runCheck runs the check of the type.
+func (c *PolicyChecker) runCheck(typ string) error {
+	switch typ {
+	case CheckTypePolicy:
+		return c.CheckPolicies()
+	case CheckTypeInlinePolicy:
+		return c.CheckInlinePolicies()
+	case CheckTypeAudit:
+		return c.Audit()
+	case CheckTypeEscalation:
+		return c.CheckEscalation()
+	case CheckTypeTrust:
+		return c.CheckTrust()
+	case CheckTypePrincipal:
+		return c.CheckPrincipals()
+	case CheckTypeLint:
+		return c.Lint()
+	}
+	return validateCheckType(typ)
+}
+
+// validateCheckType checks if the check type is supported.
+func validateCheckType(typ string) error {
+	switch typ {
+	case CheckTypePolicy,
+		CheckTypeInlinePolicy,
+		CheckTypeAudit,
+		CheckTypeEscalation,
+		CheckTypeTrust,
+		CheckTypePrincipal,
+		CheckTypeLint:
+		return nil
+	}
+	return fmt.Errorf("unsupported check type: '%s'", typ)
+}
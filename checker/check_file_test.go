@@ -0,0 +1,341 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+	"gopkg.in/yaml.v2"
+)
+
+// parseCheckFile parses YAML of the config file without validation.
+func parseCheckFile(t *testing.T, data string) CheckFile {
+	t.Helper()
+	f := CheckFile{}
+	if err := yaml.UnmarshalStrict([]byte(data), &f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestCheckToConfig(t *testing.T) {
+	common := `
+input: details.json
+parallel: 4
+max_retry: 3
+catalog: catalog.json
+catalog_strict: true
+strict: true
+suppression: suppressions.yaml
+fail_on_match: true
+fail_on: high
+log_level: warn
+log_format: json
+`
+	tests := []struct {
+		name  string
+		check string
+		want  Config
+	}{
+		{
+			name: "policy",
+			check: `
+  - name: s3-writers
+    resource: [arn:aws:s3:::prod-*, arn:aws:s3:::logs-*]
+    action: s3:Put* s3:Delete*
+    service: s3
+    access_level: [write, permissions-management]
+    filter: NOT condition:aws:SourceIp
+    scope: local
+    include_unattached: true
+    all_versions: true
+    wildcard: true
+    unconditional: true
+    missing_condition: aws:MultiFactorAuthPresent
+    trusted_account: "210987654321"
+    exclude:
+      resource: arn:aws:s3:::logs-dev
+      action: [s3:PutObjectTagging]
+      entity: role/AWSServiceRoleFor* user/admin
+      policy: [AWSServiceRole*]
+    format: json
+    error_output: errors.json
+`,
+			want: Config{
+				OutputFile:             "s3-writers.csv",
+				OutputFormat:           "json",
+				ErrorOutputFile:        "errors.json",
+				InputFile:              "details.json",
+				Parallel:               4,
+				MaxRetry:               3,
+				ActionCatalogFile:      "catalog.json",
+				CatalogStrict:          true,
+				Strict:                 true,
+				SuppressionFile:        "suppressions.yaml",
+				FailOnMatch:            true,
+				LogLevel:               "warn",
+				LogFormat:              "json",
+				TargetResource:         "arn:aws:s3:::prod-* arn:aws:s3:::logs-*",
+				TargetAction:           "s3:Put* s3:Delete*",
+				TargetActionService:    "s3",
+				AccessLevel:            "write permissions-management",
+				Filter:                 "NOT condition:aws:SourceIp",
+				PolicyScope:            "local",
+				IncludeUnattached:      true,
+				AllVersions:            true,
+				MatchStatementWildcard: true,
+				OnlyUnconditional:      true,
+				MissingCondition:       "aws:MultiFactorAuthPresent",
+				TrustedAccount:         "210987654321",
+				ExcludeResource:        "arn:aws:s3:::logs-dev",
+				ExcludeAction:          "s3:PutObjectTagging",
+				ExcludeEntity:          "role/AWSServiceRoleFor* user/admin",
+				ExcludePolicy:          "AWSServiceRole*",
+			},
+		},
+		{
+			name: "audit",
+			check: `
+  - name: audit
+    type: Audit
+    rule: full-admin iam-admin
+    trusted_account: [210987654321]
+    fail_on: critical
+    resource: ignored
+    exclude:
+      entity: [user/admin]
+      action: ignored
+`,
+			want: Config{
+				OutputFile:        "audit.csv",
+				InputFile:         "details.json",
+				Parallel:          4,
+				MaxRetry:          3,
+				ActionCatalogFile: "catalog.json",
+				CatalogStrict:     true,
+				Strict:            true,
+				SuppressionFile:   "suppressions.yaml",
+				FailOnMatch:       true,
+				FailOn:            "critical",
+				LogLevel:          "warn",
+				LogFormat:         "json",
+				ShowAllPolicy:     true,
+				AuditRule:         "full-admin iam-admin",
+				TrustedAccount:    "210987654321",
+				ExcludeEntity:     "user/admin",
+			},
+		},
+		{
+			name: "default fail_on of the check with severity",
+			check: `
+  - name: lint
+    type: lint
+    output: lint.json
+`,
+			want: Config{
+				OutputFile:        "lint.json",
+				InputFile:         "details.json",
+				Parallel:          4,
+				MaxRetry:          3,
+				ActionCatalogFile: "catalog.json",
+				CatalogStrict:     true,
+				Strict:            true,
+				SuppressionFile:   "suppressions.yaml",
+				FailOnMatch:       true,
+				FailOn:            "high",
+				LogLevel:          "warn",
+				LogFormat:         "json",
+				ShowAllPolicy:     true,
+			},
+		},
+		{
+			name: "fail_on is not used in the check without severity",
+			check: `
+  - name: principals
+    type: principal
+    fail_on_match: false
+`,
+			want: Config{
+				OutputFile:        "principals.csv",
+				InputFile:         "details.json",
+				Parallel:          4,
+				MaxRetry:          3,
+				ActionCatalogFile: "catalog.json",
+				CatalogStrict:     true,
+				Strict:            true,
+				SuppressionFile:   "suppressions.yaml",
+				FailOnMatch:       true,
+				LogLevel:          "warn",
+				LogFormat:         "json",
+				ShowAllPolicy:     true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := parseCheckFile(t, common+"checks:"+tt.check)
+			if got := f.Checks[0].ToConfig(f); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckToConfigLogger(t *testing.T) {
+	logger, err := NewStdLogger(os.Stderr, "error", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := CheckFile{Logger: logger, Checks: []Check{{Name: "a", All: true}}}
+	if got := f.Checks[0].ToConfig(f).Logger; got != logger {
+		t.Errorf("Logger = %v, want %v", got, logger)
+	}
+}
+
+func TestStringListUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		data string
+		want StringList
+	}{
+		{`v: [s3, sns]`, StringList{"s3", "sns"}},
+		{`v: s3 sns`, StringList{"s3", "sns"}},
+		{`v: "  s3   sns "`, StringList{"s3", "sns"}},
+		{`v: s3`, StringList{"s3"}},
+		{`v: 012345678901`, StringList{"012345678901"}},
+		{`v: []`, StringList{}},
+	}
+	for _, tt := range tests {
+		var v struct {
+			V StringList `yaml:"v"`
+		}
+		if err := yaml.Unmarshal([]byte(tt.data), &v); err != nil {
+			t.Errorf("%s: %v", tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(v.V, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.data, v.V, tt.want)
+		}
+	}
+
+	var v struct {
+		V StringList `yaml:"v"`
+	}
+	if err := yaml.Unmarshal([]byte(`v: {a: b}`), &v); err == nil {
+		t.Error("map is parsed as StringList")
+	}
+}
+
+func TestCheckFileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", "checks:\n  - name: a\n    all: true\n  - name: b\n    type: audit\n    fail_on: high\n", ""},
+		{"no check", "input: x\n", "config file does not contain any checks"},
+		{"empty name", "checks:\n  - all: true\n", "check name is empty"},
+		{"duplicate name", "checks:\n  - name: a\n    all: true\n  - name: a\n    all: true\n    output: b.csv\n", "duplicate check name: 'a'"},
+		{"unknown type", "checks:\n  - name: a\n    type: foo\n", "check 'a': unsupported check type: 'foo'"},
+		{"no rule", "checks:\n  - name: a\n", "check 'a': Config does not contain valid rules"},
+		{"fail_on without severity", "checks:\n  - name: a\n    all: true\n    fail_on: high\n", "check 'a': fail_on cannot be used in 'policy' check without severity"},
+		{"invalid fail_on", "fail_on: urgent\nchecks:\n  - name: a\n    all: true\n", "unknown severity: 'urgent'"},
+		{"same output", "checks:\n  - name: a\n    all: true\n    output: x.csv\n  - name: b\n    type: trust\n    output: x.csv\n", "check 'b': output file 'x.csv' is used in check 'a'"},
+		{"invalid filter", "checks:\n  - name: a\n    filter: 'service:s3 AND'\n", "check 'a': "},
+		{"unknown action in catalog strict mode", "catalog_strict: true\nchecks:\n  - name: a\n    action: s3:GetObjets\n", "check 'a': unknown-action: 's3:GetObjets' (did you mean 's3:GetObject'?)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseCheckFile(t, tt.data).Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() error = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadCheckFileUnknownKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "checks.yaml")
+	if err := os.WriteFile(file, []byte("checks:\n  - name: a\n    all: true\n    servce: s3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCheckFile(file); err == nil || !strings.Contains(err.Error(), "servce") {
+		t.Errorf("ReadCheckFile() error = %v, want unknown key", err)
+	}
+}
+
+func TestCheckFileSelect(t *testing.T) {
+	f := CheckFile{Checks: []Check{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+
+	got, err := f.Select([]string{"c", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Checks) != 2 || got.Checks[0].Name != "c" || got.Checks[1].Name != "a" {
+		t.Errorf("Select() = %+v", got.Checks)
+	}
+	if _, err := f.Select([]string{"d"}); err == nil || err.Error() != "unknown check: 'd'" {
+		t.Errorf("Select() error = %v", err)
+	}
+	if got, _ := f.Select(nil); len(got.Checks) != 3 {
+		t.Errorf("Select(nil) = %d checks, want 3", len(got.Checks))
+	}
+}
+
+// countingClient counts the calls of ListPolicies and ListUsers.
+type countingClient struct {
+	IAMClient
+	listPolicies, listUsers int32
+}
+
+func (c *countingClient) ListPolicies(scope string, onlyAttached bool) ([]iam.Policy, error) {
+	atomic.AddInt32(&c.listPolicies, 1)
+	return c.IAMClient.ListPolicies(scope, onlyAttached)
+}
+
+func (c *countingClient) ListUsers() ([]iam.User, error) {
+	atomic.AddInt32(&c.listUsers, 1)
+	return c.IAMClient.ListUsers()
+}
+
+func TestCheckFileRunWithClient(t *testing.T) {
+	dir := t.TempDir()
+	f := CheckFile{
+		LogLevel: "error",
+		Checks: []Check{
+			{Name: "policy", All: true, Output: filepath.Join(dir, "policy.csv")},
+			{Name: "inline", Type: CheckTypeInlinePolicy, All: true, Output: filepath.Join(dir, "inline.json")},
+			{Name: "principal", Type: CheckTypePrincipal, Output: filepath.Join(dir, "principal.csv")},
+		},
+	}
+	snap, err := NewSnapshotClient(testSnapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &countingClient{IAMClient: snap}
+	summaries, err := f.RunWithClient(cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(summaries) != len(f.Checks) {
+		t.Fatalf("RunWithClient() = %d summaries, want %d", len(summaries), len(f.Checks))
+	}
+	for i, s := range summaries {
+		if s.Name != f.Checks[i].Name {
+			t.Errorf("summaries[%d].Name = %s, want %s", i, s.Name, f.Checks[i].Name)
+		}
+		if _, err := os.Stat(f.Checks[i].Output); err != nil {
+			t.Error(err)
+		}
+	}
+	if cli.listPolicies != 1 || cli.listUsers != 1 {
+		t.Errorf("ListPolicies is called %d times and ListUsers is called %d times, want once", cli.listPolicies, cli.listUsers)
+	}
+}
@@ -2,7 +2,9 @@ package checker
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"sort"
 	"strings"
 )
 
@@ -12,44 +14,226 @@ const (
 
 	// enviroment parameters
 	envKeyOutputFile     = "POLICY_CHECKER_OUTPUT_FILE"
+	envKeyInputFile      = "POLICY_CHECKER_INPUT_FILE"
 	envKeyTargetResource = "POLICY_CHECKER_TARGET_RESOURCE"
 	envKeyTargetAction   = "POLICY_CHECKER_TARGET_ACTION"
 	// service name. use comma for multiple services. (ref: https://docs.aws.amazon.com/general/latest/gr/aws-arns-and-namespaces.html)
 	envKeyTargetActionService = "POLICY_CHECKER_TARGET_ACTION_SERVICE"
+	// condition key. use space for multiple keys. (e.g. aws:MultiFactorAuthPresent)
+	envKeyMissingCondition = "POLICY_CHECKER_MISSING_CONDITION"
+	// account ID. use space for multiple accounts.
+	envKeyTrustedAccount = "POLICY_CHECKER_TRUSTED_ACCOUNT"
+	// access level of actions. use comma or space for multiple levels. (e.g. write,permissions-management)
+	envKeyAccessLevel = "POLICY_CHECKER_ACCESS_LEVEL"
+	// JSON file of the action catalog used instead of the built-in catalog.
+	envKeyActionCatalog = "POLICY_CHECKER_ACTION_CATALOG"
+	// exclusion rules. use space for multiple rules.
+	envKeyExcludeResource = "POLICY_CHECKER_EXCLUDE_RESOURCE"
+	envKeyExcludeAction   = "POLICY_CHECKER_EXCLUDE_ACTION"
+	envKeyExcludeEntity   = "POLICY_CHECKER_EXCLUDE_ENTITY"
+	envKeyExcludePolicy   = "POLICY_CHECKER_EXCLUDE_POLICY"
+	// filter expression evaluated per statement. (e.g. `service:s3 AND action:Put*`)
+	envKeyFilter = "POLICY_CHECKER_FILTER"
+	// log level and format. (e.g. `debug`, `json`)
+	envKeyLogLevel  = "POLICY_CHECKER_LOG_LEVEL"
+	envKeyLogFormat = "POLICY_CHECKER_LOG_FORMAT"
+	// YAML file of the suppressions.
+	envKeySuppressionFile = "POLICY_CHECKER_SUPPRESSION_FILE"
 )
 
 var (
 	envValueOutputFile          = os.Getenv(envKeyOutputFile)
+	envValueInputFile           = os.Getenv(envKeyInputFile)
 	envValueTargetResource      = os.Getenv(envKeyTargetResource)
 	envValueTargetAction        = os.Getenv(envKeyTargetAction)
 	envValueTargetActionService = os.Getenv(envKeyTargetActionService)
+	envValueMissingCondition    = os.Getenv(envKeyMissingCondition)
+	envValueTrustedAccount      = os.Getenv(envKeyTrustedAccount)
+	envValueAccessLevel         = os.Getenv(envKeyAccessLevel)
+	envValueActionCatalog       = os.Getenv(envKeyActionCatalog)
+	envValueExcludeResource     = os.Getenv(envKeyExcludeResource)
+	envValueExcludeAction       = os.Getenv(envKeyExcludeAction)
+	envValueExcludeEntity       = os.Getenv(envKeyExcludeEntity)
+	envValueExcludePolicy       = os.Getenv(envKeyExcludePolicy)
+	envValueFilter              = os.Getenv(envKeyFilter)
+	envValueLogLevel            = os.Getenv(envKeyLogLevel)
+	envValueLogFormat           = os.Getenv(envKeyLogFormat)
+	envValueSuppressionFile     = os.Getenv(envKeySuppressionFile)
 )
 
 // Config contains settings.
 type Config struct {
-	OutputFile          string
-	TargetResource      string // space separated
-	TargetAction        string // space separated
-	TargetActionService string // space separated
-	ShowAllPolicy       bool
+	OutputFile             string
+	OutputFormat           string // csv, tsv, json or ndjson (default: decided by the file extension)
+	InputFile              string // JSON file of `aws iam get-account-authorization-details`
+	TargetResource         string // space separated
+	TargetAction           string // space separated
+	TargetActionService    string // space separated
+	AccessLevel            string // comma or space separated; list, read, write, permissions-management or tagging
+	ActionCatalogFile      string // JSON file of the action catalog (default: the built-in catalog)
+	CatalogStrict          bool   // return error for unknown actions of the services in the catalog in the action rules
+	ExcludeResource        string // space separated; resources not to be matched
+	ExcludeAction          string // space separated; actions not to be matched
+	ExcludeEntity          string // space separated; User/Group/Role names not to be checked (e.g. `role/AWSServiceRoleFor*`)
+	ExcludePolicy          string // space separated; policy names or ARNs not to be checked (e.g. `AWSServiceRole*`)
+	Filter                 string // filter expression evaluated per statement (e.g. `service:s3 AND action:Put* AND resource:arn:aws:s3:::prod-*`)
+	ShowAllPolicy          bool
+	PolicyScope            string // scope of managed policies; aws, local or all (default: all)
+	IncludeUnattached      bool   // include managed policies not attached to any entity
+	AllVersions            bool   // check non-default versions of managed policies and report versions broader than the default version
+	MatchStatementWildcard bool   // match when the wildcard in the statement covers the target (e.g. `s3:*` covers `s3:GetObject`)
+	OnlyUnconditional      bool   // match only statements without Condition
+	MissingCondition       string // space separated; match only statements without the condition key
+	AuditRule              string // space separated; rule names used in Audit (default: all of the built-in rules)
+	TrustedAccount         string // space separated; external account IDs allowed in trust policies
+	Parallel               int    // number of concurrent API calls (default: 1)
+	MaxRetry               int    // max retry count on throttling error (default: 5)
+	ErrorOutputFile        string // output file of the skipped items by errors (default: `<output>.errors.<ext>`)
+	Strict                 bool   // return error when any item is skipped by errors
+	FailOnMatch            bool   // return error when any finding exists
+	FailOn                 string // return error when any finding is at or above the severity (e.g. `high`)
+	SuppressionFile        string // YAML file of the accepted exceptions
+	SuppressedOutputFile   string // output file of the suppressed results (default: `<output>.suppressed.<ext>`)
+	LogLevel               string // debug, info, warn or error (default: info)
+	LogFormat              string // text or json (default: text)
+	Logger                 Logger // custom logger used instead of the default logger writing into stderr
 
-	targetResources []string
-	targetActions   []string
-	targetServices  *TargetService
+	targetResources  []string
+	targetActions    []string
+	targetServices   *TargetService
+	missingConds     []string
+	trustedAccounts  []string
+	accessLevels     []string
+	actionCatalog    *ActionCatalog
+	excludeResources []string
+	excludeActions   []string
+	excludeEntities  []string
+	excludePolicies  []string
+	filter           FilterExpression
+	suppressions     []Suppression
 }
 
 // Validate validates config has valid rules or not.
 func (c Config) Validate() error {
+	if c.OutputFormat != "" {
+		if err := validateFormat(strings.ToLower(c.OutputFormat)); err != nil {
+			return err
+		}
+	}
+
+	if err := validatePolicyScope(c.PolicyScope); err != nil {
+		return err
+	}
+	if err := validateLogLevel(c.GetLogLevel()); err != nil {
+		return err
+	}
+	if err := validateLogFormat(c.GetLogFormat()); err != nil {
+		return err
+	}
+	if c.FailOn != "" {
+		if _, err := ParseSeverity(c.FailOn); err != nil {
+			return err
+		}
+	}
+	if file := c.GetSuppressionFile(); file != "" {
+		if _, err := ReadSuppressionFile(file); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range toStringList(toSpaceSeparated(c.AccessLevel)) {
+		if _, err := normalizeAccessLevel(v); err != nil {
+			return err
+		}
+	}
+
+	if c.GetFilterExpression() != "" {
+		if _, err := ParseFilter(c.GetFilterExpression()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := c.ValidateTargets(); err != nil {
+		return err
+	}
+
 	switch {
 	case c.ShowAllPolicy,
 		c.TargetResource != "",
 		c.TargetAction != "",
-		c.TargetActionService != "":
+		c.TargetActionService != "",
+		c.AccessLevel != "",
+		c.Filter != "":
 		return nil
 	}
 	return errors.New("Config does not contain valid rules")
 }
 
+// ValidateTargets checks the action and service rules by the action catalog.
+// It returns warnings for unknown actions (e.g. `s3:GetObjets`) and the rules which cannot be checked by the catalog (e.g. services not in the catalog).
+// Unknown actions of the services in the catalog are error in CatalogStrict mode.
+func (c *Config) ValidateTargets() (warnings []string, err error) {
+	catalog, err := c.GetActionCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0, len(c.GetTargetActionServices().Map))
+	for svc := range c.GetTargetActionServices().Map {
+		services = append(services, svc)
+	}
+
+	var filterActions []string
+	for _, t := range getFilterTerms(c.GetFilter()) {
+		switch t.key {
+		case filterKeyAction:
+			filterActions = append(filterActions, t.value)
+		case filterKeyService:
+			services = append(services, strings.ToLower(t.value))
+		}
+	}
+
+	for _, v := range c.GetTargetActions() {
+		warning, err := lintTargetAction(catalog, v, v, c.CatalogStrict)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+	for _, v := range filterActions {
+		warning, err := lintTargetAction(catalog, v, toFilterActionPattern(v), c.CatalogStrict)
+		if err != nil {
+			return nil, err
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+	}
+
+	for _, svc := range services {
+		if !catalog.HasService(svc) {
+			warnings = append(warnings, formatLintIssue(LintIssueUnknownService, svc, catalog.suggestService(svc)))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings, nil
+}
+
+// lintTargetAction checks the action rule as the pattern by the catalog.
+// The issues are warning, and unknown action of the service in the catalog is error when strict is true.
+func lintTargetAction(catalog *ActionCatalog, rule, pattern string, strict bool) (warning string, err error) {
+	issue, suggestion := catalog.lintTargetRule(pattern)
+	switch {
+	case issue == "":
+		return "", nil
+	case strict && issue == LintIssueUnknownAction && strings.Contains(rule, ":"):
+		return "", errors.New(formatLintIssue(issue, rule, suggestion))
+	}
+	return formatLintIssue(issue, rule, suggestion), nil
+}
+
 // GetOutputFile gets output file name.
 func (c Config) GetOutputFile() string {
 	switch {
@@ -62,6 +246,118 @@ func (c Config) GetOutputFile() string {
 	}
 }
 
+// GetErrorOutputFile gets output file name of the skipped items by errors.
+func (c Config) GetErrorOutputFile() string {
+	if c.ErrorOutputFile != "" {
+		return c.ErrorOutputFile
+	}
+	return toErrorOutputFile(c.GetOutputFile())
+}
+
+// GetFailOnSeverity gets the severity threshold of the findings.
+// It returns SeverityUnknown when the threshold is not set.
+func (c Config) GetFailOnSeverity() Severity {
+	s, _ := ParseSeverity(c.FailOn)
+	return s
+}
+
+// GetSuppressionFile gets YAML file name of the suppressions.
+func (c Config) GetSuppressionFile() string {
+	if c.SuppressionFile != "" {
+		return c.SuppressionFile
+	}
+	return envValueSuppressionFile
+}
+
+// GetSuppressedOutputFile gets output file name of the suppressed results.
+func (c Config) GetSuppressedOutputFile() string {
+	if c.SuppressedOutputFile != "" {
+		return c.SuppressedOutputFile
+	}
+	return toSuppressedOutputFile(c.GetOutputFile())
+}
+
+// GetSuppressions gets the suppressions from the suppression file.
+func (c *Config) GetSuppressions() ([]Suppression, error) {
+	if c.suppressions != nil {
+		return c.suppressions, nil
+	}
+
+	file := c.GetSuppressionFile()
+	if file == "" {
+		return nil, nil
+	}
+
+	list, err := ReadSuppressionFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c.suppressions = list
+	return c.suppressions, nil
+}
+
+// hasSuppression checks if config has the suppression file.
+func (c *Config) hasSuppression() bool {
+	return c.GetSuppressionFile() != ""
+}
+
+// GetInputFile gets input file name.
+// When it's empty, IAM data is fetched from AWS API.
+func (c Config) GetInputFile() string {
+	if c.InputFile != "" {
+		return c.InputFile
+	}
+	return envValueInputFile
+}
+
+// GetParallel gets number of concurrent API calls.
+func (c Config) GetParallel() int {
+	if c.Parallel > 0 {
+		return c.Parallel
+	}
+	return defaultParallel
+}
+
+// GetMaxRetry gets max retry count on throttling error.
+func (c Config) GetMaxRetry() int {
+	if c.MaxRetry > 0 {
+		return c.MaxRetry
+	}
+	return defaultMaxRetry
+}
+
+// GetLogLevel gets log level.
+func (c Config) GetLogLevel() string {
+	if c.LogLevel != "" {
+		return c.LogLevel
+	}
+	return envValueLogLevel
+}
+
+// GetLogFormat gets log format.
+func (c Config) GetLogFormat() string {
+	if c.LogFormat != "" {
+		return c.LogFormat
+	}
+	return envValueLogFormat
+}
+
+// GetLogger gets the custom logger, or the default logger writing into stderr.
+func (c Config) GetLogger() Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return newDefaultLogger(c.GetLogLevel(), c.GetLogFormat())
+}
+
+// GetPolicyScope gets scope of managed policies.
+func (c Config) GetPolicyScope() string {
+	if c.PolicyScope == "" {
+		return PolicyScopeAll
+	}
+	return strings.ToLower(c.PolicyScope)
+}
+
 // GetTargetResources gets filter rule for policy resource.
 func (c *Config) GetTargetResources() []string {
 	if c.targetResources != nil {
@@ -94,10 +390,222 @@ func (c *Config) GetTargetActionServices() *TargetService {
 	}
 
 	svc := newTargetService(list)
+	svc.useStatementWildcard = c.MatchStatementWildcard
 	c.targetServices = &svc
 	return c.targetServices
 }
 
+// GetExcludeResources gets exclusion rule for policy resource.
+func (c *Config) GetExcludeResources() []string {
+	if c.excludeResources != nil {
+		return c.excludeResources
+	}
+
+	c.excludeResources = toStringList(c.ExcludeResource, envValueExcludeResource)
+	return c.excludeResources
+}
+
+// GetExcludeActions gets exclusion rule for policy action.
+func (c *Config) GetExcludeActions() []string {
+	if c.excludeActions != nil {
+		return c.excludeActions
+	}
+
+	c.excludeActions = toStringList(c.ExcludeAction, envValueExcludeAction)
+	return c.excludeActions
+}
+
+// GetExcludeEntities gets exclusion rule for User/Group/Role names.
+func (c *Config) GetExcludeEntities() []string {
+	if c.excludeEntities != nil {
+		return c.excludeEntities
+	}
+
+	c.excludeEntities = toStringList(c.ExcludeEntity, envValueExcludeEntity)
+	return c.excludeEntities
+}
+
+// GetExcludePolicies gets exclusion rule for policy names or ARNs.
+func (c *Config) GetExcludePolicies() []string {
+	if c.excludePolicies != nil {
+		return c.excludePolicies
+	}
+
+	c.excludePolicies = toStringList(c.ExcludePolicy, envValueExcludePolicy)
+	return c.excludePolicies
+}
+
+// GetMissingConditionKeys gets filter rule for condition keys which the statement does not have.
+func (c *Config) GetMissingConditionKeys() []string {
+	if c.missingConds != nil {
+		return c.missingConds
+	}
+
+	c.missingConds = toStringList(c.MissingCondition, envValueMissingCondition)
+	return c.missingConds
+}
+
+// GetTrustedAccounts gets external account IDs allowed in trust policies.
+func (c *Config) GetTrustedAccounts() []string {
+	if c.trustedAccounts != nil {
+		return c.trustedAccounts
+	}
+
+	c.trustedAccounts = toStringList(c.TrustedAccount, envValueTrustedAccount)
+	return c.trustedAccounts
+}
+
+// GetAccessLevels gets filter rule for access levels of actions. (e.g. `Permissions management`)
+func (c *Config) GetAccessLevels() []string {
+	if c.accessLevels != nil {
+		return c.accessLevels
+	}
+
+	list := toStringList(toSpaceSeparated(c.AccessLevel), toSpaceSeparated(envValueAccessLevel))
+	result := make([]string, 0, len(list))
+	for _, v := range list {
+		// invalid levels are checked in Validate.
+		if level, err := normalizeAccessLevel(v); err == nil {
+			result = append(result, level)
+		}
+	}
+	c.accessLevels = result
+	return c.accessLevels
+}
+
+// GetActionCatalogFile gets JSON file of the action catalog.
+// When it's empty, the built-in catalog is used.
+func (c Config) GetActionCatalogFile() string {
+	if c.ActionCatalogFile != "" {
+		return c.ActionCatalogFile
+	}
+	return envValueActionCatalog
+}
+
+// GetActionCatalog gets the action catalog from the file or the built-in catalog.
+// The built-in catalog is returned with the error when the file cannot be read.
+func (c *Config) GetActionCatalog() (*ActionCatalog, error) {
+	if c.actionCatalog != nil {
+		return c.actionCatalog, nil
+	}
+
+	file := c.GetActionCatalogFile()
+	if file == "" {
+		c.actionCatalog = DefaultActionCatalog()
+		return c.actionCatalog, nil
+	}
+
+	catalog, err := ReadActionCatalogFile(file)
+	if err != nil {
+		return DefaultActionCatalog(), err
+	}
+	c.actionCatalog = catalog
+	return c.actionCatalog, nil
+}
+
+// GetFilterExpression gets the filter expression.
+func (c Config) GetFilterExpression() string {
+	if c.Filter != "" {
+		return c.Filter
+	}
+	return envValueFilter
+}
+
+// GetFilter gets the parsed filter expression. It returns nil when the filter is empty or invalid.
+func (c *Config) GetFilter() FilterExpression {
+	if c.filter != nil {
+		return c.filter
+	}
+
+	expr := c.GetFilterExpression()
+	if expr == "" {
+		return nil
+	}
+	// invalid filter is checked in Validate.
+	if f, err := ParseFilter(expr); err == nil {
+		c.filter = f
+	}
+	return c.filter
+}
+
+// GetAuditRules gets rules used in Audit.
+func (c Config) GetAuditRules() ([]Rule, error) {
+	rules := DefaultRules()
+	names := toStringList(c.AuditRule)
+	if len(names) == 0 {
+		return rules, nil
+	}
+
+	m := make(map[string]Rule, len(rules))
+	for _, r := range rules {
+		m[r.Name] = r
+	}
+
+	result := make([]Rule, 0, len(names))
+	for _, name := range names {
+		r, ok := m[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown rule: '%s'", name)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// loadCache fills the caches of the rules.
+// It's called before the workers of runParallel read the config concurrently, so the getters do not write the caches after this.
+func (c *Config) loadCache() {
+	c.GetTargetResources()
+	c.GetTargetActions()
+	c.GetTargetActionServices()
+	c.GetExcludeResources()
+	c.GetExcludeActions()
+	c.GetExcludeEntities()
+	c.GetExcludePolicies()
+	c.GetMissingConditionKeys()
+	c.GetTrustedAccounts()
+	c.GetAccessLevels()
+	c.GetActionCatalog()
+	c.GetFilter()
+	c.GetSuppressions()
+}
+
+// hasConditionRule checks if config has filter rules for Condition block.
+func (c *Config) hasConditionRule() bool {
+	return c.OnlyUnconditional || len(c.GetMissingConditionKeys()) != 0
+}
+
+// hasAccessLevelRule checks if config has filter rules for access levels of actions.
+func (c *Config) hasAccessLevelRule() bool {
+	return len(c.GetAccessLevels()) != 0
+}
+
+// hasFilterRule checks if config has the filter expression.
+func (c *Config) hasFilterRule() bool {
+	return c.GetFilter() != nil
+}
+
+// hasTargetRule checks if config has filter rules for Resource/Action/Service.
+func (c *Config) hasTargetRule() bool {
+	return len(c.GetTargetResources()) != 0 ||
+		len(c.GetTargetActions()) != 0 ||
+		c.GetTargetActionServices().hasService()
+}
+
+// validatePolicyScope checks if the scope of managed policies is supported.
+func validatePolicyScope(scope string) error {
+	switch strings.ToLower(scope) {
+	case "", PolicyScopeAll, PolicyScopeAWS, PolicyScopeLocal:
+		return nil
+	}
+	return fmt.Errorf("unsupported policy scope: '%s'", scope)
+}
+
+// toSpaceSeparated replaces commas with spaces for the rules which accept both of them.
+func toSpaceSeparated(s string) string {
+	return strings.ReplaceAll(s, ",", defaultSeparator)
+}
+
 func toStringList(inputs ...string) []string {
 	result := make([]string, 0)
 
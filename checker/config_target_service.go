@@ -7,12 +7,14 @@ import (
 // TargetService checks the action cotains target service.
 type TargetService struct {
 	Map map[string]interface{}
+
+	useStatementWildcard bool
 }
 
 func newTargetService(services []string) TargetService {
 	m := make(map[string]interface{})
 	for _, v := range services {
-		v = strings.TrimSpace(v)
+		v = strings.ToLower(strings.TrimSpace(v))
 		if v == "" {
 			continue
 		}
@@ -39,7 +41,41 @@ func (s TargetService) HasTargetInActions(actions []string) bool {
 	return false
 }
 
+// grantedActions returns actions of the target services granted by the statement.
+// When the statement uses NotAction, `<service>:*` is returned for the services not excluded.
+// NotAction covers actions of all services like `*`, so it's used only when useStatementWildcard is true.
+func (s TargetService) grantedActions(st Statement) []string {
+	if !s.hasService() {
+		return nil
+	}
+
+	var result []string
+	if len(st.NotAction) != 0 {
+		if !s.useStatementWildcard {
+			return nil
+		}
+		for svc := range s.Map {
+			if pattern := svc + ":*"; !isExcludedByNotAction(st, pattern) {
+				result = append(result, pattern)
+			}
+		}
+		return result
+	}
+
+	for _, action := range st.Action {
+		if s.isTargetAction(action) {
+			result = append(result, action)
+		}
+	}
+	return result
+}
+
 func (s TargetService) isTargetAction(action string) bool {
+	if action == "*" {
+		// `*` covers actions of all services.
+		return s.useStatementWildcard
+	}
+
 	parts := strings.Split(action, ":")
 	if len(parts) != 2 {
 		return false
@@ -48,7 +84,7 @@ func (s TargetService) isTargetAction(action string) bool {
 }
 
 func (s TargetService) isTarget(service string) bool {
-	_, ok := s.Map[service]
+	_, ok := s.Map[strings.ToLower(service)]
 	return ok
 }
 
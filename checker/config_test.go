@@ -0,0 +1,95 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         Config
+		wantWarnings string
+		wantErr      string
+	}{
+		{
+			name: "known actions",
+			conf: Config{TargetAction: "s3:GetObject s3:Get iam:* Delete", TargetActionService: "s3"},
+		},
+		{
+			name:         "unknown action is warning",
+			conf:         Config{TargetAction: "s3:GetObjets"},
+			wantWarnings: "unknown-action: 's3:GetObjets' (did you mean 's3:GetObject'?)",
+		},
+		{
+			name:         "action not in partial service is warning",
+			conf:         Config{TargetAction: "s3:PutStorageLensConfiguration kms:ReplicateKey"},
+			wantWarnings: "unknown-action: 'kms:ReplicateKey'\nunknown-action: 's3:PutStorageLensConfiguration'",
+		},
+		{
+			name:         "unknown service",
+			conf:         Config{TargetAction: "glue:*", TargetActionService: "sns sqs rds"},
+			wantWarnings: "unknown-service: 'glue:*'\nunknown-service: 'rds'",
+		},
+		{
+			name:         "filter expression",
+			conf:         Config{Filter: "action:s3:GetObjets OR service:s33"},
+			wantWarnings: "unknown-action: 's3:GetObjets'\nunknown-service: 's33' (did you mean 's3'?)",
+		},
+		{
+			name:    "unknown action in strict mode",
+			conf:    Config{TargetAction: "s3:GetObjets", CatalogStrict: true},
+			wantErr: "unknown-action: 's3:GetObjets' (did you mean 's3:GetObject'?)",
+		},
+		{
+			name:    "unknown action of filter in strict mode",
+			conf:    Config{Filter: "action:kms:ReplicateKey", CatalogStrict: true},
+			wantErr: "unknown-action: 'kms:ReplicateKey'",
+		},
+		{
+			name:         "unknown service in strict mode",
+			conf:         Config{TargetAction: "glue:GetTable", CatalogStrict: true},
+			wantWarnings: "unknown-service: 'glue:GetTable'",
+		},
+		{
+			name:         "substring in strict mode",
+			conf:         Config{TargetAction: "Delx", CatalogStrict: true},
+			wantWarnings: "unknown-action: 'Delx'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := tt.conf.ValidateTargets()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ValidateTargets() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateTargets() error = %v", err)
+			}
+			if got := strings.Join(warnings, "\n"); got != tt.wantWarnings {
+				t.Errorf("ValidateTargets() warnings = %q, want %q", got, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestNewWithClientUnknownAction(t *testing.T) {
+	c, buf := newTestChecker(t, Config{TargetAction: "kms:ReplicateKey"})
+	if !strings.Contains(buf.String(), "Func:[ValidateTargets] Warning:[unknown-action: 'kms:ReplicateKey']") {
+		t.Errorf("warning is not logged: %s", buf.String())
+	}
+	if _, err := c.CollectPolicies(); err != nil {
+		t.Fatal(err)
+	}
+
+	cli, err := NewSnapshotClient(testSnapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWithClient(Config{TargetAction: "kms:ReplicateKey", CatalogStrict: true}, cli); err == nil {
+		t.Error("NewWithClient() error = nil in CatalogStrict mode")
+	}
+}
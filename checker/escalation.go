@@ -0,0 +1,126 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EscalationPath is a combination of permissions to escalate privileges.
+// (ref: https://rhinosecuritylabs.com/aws/aws-privilege-escalation-methods-mitigation/)
+type EscalationPath struct {
+	Name        string   `json:"name"`
+	Actions     []string `json:"actions"`
+	Description string   `json:"description"`
+}
+
+// DefaultEscalationPaths returns known privilege escalation paths.
+func DefaultEscalationPaths() []EscalationPath {
+	return []EscalationPath{
+		{Name: "create-policy-version", Actions: []string{"iam:CreatePolicyVersion"}, Description: "create a new version of the managed policy with admin permissions"},
+		{Name: "set-default-policy-version", Actions: []string{"iam:SetDefaultPolicyVersion"}, Description: "switch the managed policy to an older version with broader permissions"},
+		{Name: "create-access-key", Actions: []string{"iam:CreateAccessKey"}, Description: "create an access key of another user"},
+		{Name: "create-login-profile", Actions: []string{"iam:CreateLoginProfile"}, Description: "create a console password of another user"},
+		{Name: "update-login-profile", Actions: []string{"iam:UpdateLoginProfile"}, Description: "change a console password of another user"},
+		{Name: "attach-user-policy", Actions: []string{"iam:AttachUserPolicy"}, Description: "attach an admin policy to the user"},
+		{Name: "attach-group-policy", Actions: []string{"iam:AttachGroupPolicy"}, Description: "attach an admin policy to the group of the user"},
+		{Name: "attach-role-policy", Actions: []string{"iam:AttachRolePolicy"}, Description: "attach an admin policy to the role"},
+		{Name: "put-user-policy", Actions: []string{"iam:PutUserPolicy"}, Description: "add an admin inline policy to the user"},
+		{Name: "put-group-policy", Actions: []string{"iam:PutGroupPolicy"}, Description: "add an admin inline policy to the group of the user"},
+		{Name: "put-role-policy", Actions: []string{"iam:PutRolePolicy"}, Description: "add an admin inline policy to the role"},
+		{Name: "add-user-to-group", Actions: []string{"iam:AddUserToGroup"}, Description: "join the group with admin permissions"},
+		{Name: "update-assume-role-policy", Actions: []string{"iam:UpdateAssumeRolePolicy", "sts:AssumeRole"}, Description: "change the trust policy of a privileged role and assume it"},
+		{Name: "passrole-lambda-invoke", Actions: []string{"iam:PassRole", "lambda:CreateFunction", "lambda:InvokeFunction"}, Description: "create and invoke a Lambda function with a privileged role"},
+		{Name: "passrole-lambda-event-source", Actions: []string{"iam:PassRole", "lambda:CreateFunction", "lambda:CreateEventSourceMapping"}, Description: "create a Lambda function with a privileged role and trigger it by an event source"},
+		{Name: "update-lambda-code", Actions: []string{"lambda:UpdateFunctionCode"}, Description: "replace the code of a Lambda function having a privileged role"},
+		{Name: "passrole-ec2", Actions: []string{"iam:PassRole", "ec2:RunInstances"}, Description: "run an EC2 instance with a privileged instance profile"},
+		{Name: "passrole-cloudformation", Actions: []string{"iam:PassRole", "cloudformation:CreateStack"}, Description: "create a CloudFormation stack with a privileged role"},
+		{Name: "passrole-datapipeline", Actions: []string{"iam:PassRole", "datapipeline:CreatePipeline", "datapipeline:PutPipelineDefinition"}, Description: "create a Data Pipeline running commands with a privileged role"},
+		{Name: "passrole-glue-dev-endpoint", Actions: []string{"iam:PassRole", "glue:CreateDevEndpoint"}, Description: "create a Glue development endpoint with a privileged role"},
+		{Name: "update-glue-dev-endpoint", Actions: []string{"glue:UpdateDevEndpoint"}, Description: "add an SSH key to a Glue development endpoint having a privileged role"},
+	}
+}
+
+// EscalationFinding is an escalation path found in the principal.
+// Restricted is true when any permission is granted only on the limited resources or with Condition,
+// so the path may not work. (e.g. `iam:PassRole` only on a role without privileges)
+type EscalationFinding struct {
+	Principal   *Principal             `json:"principal"`
+	Path        EscalationPath         `json:"path"`
+	Permissions []EscalationPermission `json:"permissions"`
+	Restricted  bool                   `json:"restricted"`
+}
+
+// EscalationPermission is a permission used in the escalation path and the policies granting it.
+type EscalationPermission struct {
+	Action   string        `json:"action"`
+	Policies []PolicyGrant `json:"policies"`
+}
+
+// IsRestricted checks if all of the policies grant the action with restriction of the resources or Condition.
+func (p EscalationPermission) IsRestricted() bool {
+	return !hasUnrestrictedGrant(p.Policies)
+}
+
+// String returns the action and the policies. (e.g. `iam:PassRole: AdminPolicy (group:developers), pass-role (inline) [conditional]`)
+func (p EscalationPermission) String() string {
+	names := make([]string, len(p.Policies))
+	for i, pp := range p.Policies {
+		names[i] = pp.String()
+	}
+	return fmt.Sprintf("%s: %s", p.Action, strings.Join(names, ", "))
+}
+
+// String returns the policy name with the source. (e.g. `AdminPolicy (group:developers)`)
+func (p PrincipalPolicy) String() string {
+	name := p.Policy.PolicyName
+	if p.Policy.GetPolicyType() == policyTypeInline {
+		name += " (inline)"
+	}
+	if p.Group != "" {
+		name += fmt.Sprintf(" (%s:%s)", entityGroup, p.Group)
+	}
+	return name
+}
+
+// findEscalationPaths checks the principals have all of the permissions in the escalation paths.
+// Principals already having all of the permissions (`*`) without restriction are skipped.
+func findEscalationPaths(paths []EscalationPath, principals []*Principal) []EscalationFinding {
+	var result []EscalationFinding
+	for _, p := range principals {
+		if hasUnrestrictedGrant(p.GrantedBy("*")) {
+			continue
+		}
+
+		for _, path := range paths {
+			perms := make([]EscalationPermission, 0, len(path.Actions))
+			for _, action := range path.Actions {
+				policies := p.GrantedBy(action)
+				if len(policies) == 0 {
+					break
+				}
+				perms = append(perms, EscalationPermission{
+					Action:   action,
+					Policies: policies,
+				})
+			}
+			if len(perms) != len(path.Actions) {
+				continue
+			}
+
+			restricted := false
+			for _, perm := range perms {
+				if perm.IsRestricted() {
+					restricted = true
+					break
+				}
+			}
+			result = append(result, EscalationFinding{
+				Principal:   p,
+				Path:        path,
+				Permissions: perms,
+				Restricted:  restricted,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// formatEscalations returns `<path>:<restricted>` of the findings.
+func formatEscalations(list []EscalationFinding) string {
+	result := make([]string, len(list))
+	for i, v := range list {
+		result[i] = v.Path.Name + ":" + strconv.FormatBool(v.Restricted)
+	}
+	return strings.Join(result, " ")
+}
+
+func TestFindEscalationPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "unrestricted permission",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:CreatePolicyVersion","Resource":"*"}]}`,
+			want: "create-policy-version:false",
+		},
+		{
+			name: "all of the permissions in the path",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":["iam:PassRole","ec2:RunInstances"],"Resource":"*"}]}`,
+			want: "passrole-ec2:false",
+		},
+		{
+			name: "part of the permissions in the path",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"ec2:RunInstances","Resource":"*"}]}`,
+			want: "",
+		},
+		{
+			name: "passrole on a role",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"iam:PassRole","Resource":"arn:aws:iam::012345678901:role/app"},
+				{"Effect":"Allow","Action":"ec2:RunInstances","Resource":"*"}
+			]}`,
+			want: "passrole-ec2:true",
+		},
+		{
+			name: "access key of the user itself",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:CreateAccessKey","Resource":"arn:aws:iam::*:user/${aws:username}"}]}`,
+			want: "create-access-key:true",
+		},
+		{
+			name: "NotResource",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:AttachRolePolicy","NotResource":"arn:aws:iam::*:role/admin"}]}`,
+			want: "attach-role-policy:true",
+		},
+		{
+			name: "conditional permission",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:AttachUserPolicy","Resource":"*","Condition":{"ArnEquals":{"iam:PolicyARN":"arn:aws:iam::aws:policy/ReadOnlyAccess"}}}]}`,
+			want: "attach-user-policy:true",
+		},
+		{
+			name: "unrestricted statement is used",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"iam:CreateAccessKey","Resource":"arn:aws:iam::*:user/${aws:username}"},
+				{"Effect":"Allow","Action":"iam:*AccessKey","Resource":"*"}
+			]}`,
+			want: "create-access-key:false",
+		},
+		{
+			name: "denied permission",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"iam:CreateAccessKey","Resource":"*"},
+				{"Effect":"Deny","Action":"iam:CreateAccessKey","Resource":"*"}
+			]}`,
+			want: "",
+		},
+		{
+			name: "admin is skipped",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Principal{
+				Type:     entityUser,
+				Name:     "foo",
+				Policies: []PrincipalPolicy{{Policy: newTestPolicy(t, "test", tt.doc)}},
+			}
+			if got := formatEscalations(findEscalationPaths(DefaultEscalationPaths(), []*Principal{p})); got != tt.want {
+				t.Errorf("findEscalationPaths() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindEscalationPathsConditionalAdmin(t *testing.T) {
+	p := &Principal{
+		Type:     entityRole,
+		Name:     "admin",
+		Policies: []PrincipalPolicy{{Policy: newTestPolicy(t, "test", `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}]}`)}},
+	}
+	paths := DefaultEscalationPaths()
+	findings := findEscalationPaths(paths, []*Principal{p})
+	if len(findings) != len(paths) {
+		t.Fatalf("findEscalationPaths() = %d findings, want %d", len(findings), len(paths))
+	}
+	for _, f := range findings {
+		if !f.Restricted {
+			t.Errorf("%s: Restricted = false, want true", f.Path.Name)
+		}
+	}
+}
+
+func TestEscalationPermissionString(t *testing.T) {
+	managed := newTestPolicy(t, "pass-role", `{"Statement":[{"Effect":"Allow","Action":"iam:PassRole","Resource":"arn:aws:iam::012345678901:role/app"}]}`)
+	inline := newTestPolicy(t, "mfa", `{"Statement":[{"Effect":"Allow","Action":"iam:PassRole","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}]}`)
+	inline.ARN = ""
+	p := &Principal{
+		Type: entityUser,
+		Name: "foo",
+		Policies: []PrincipalPolicy{
+			{Policy: managed},
+			{Policy: inline, Group: "developers"},
+		},
+	}
+
+	perm := EscalationPermission{Action: "iam:PassRole", Policies: p.GrantedBy("iam:PassRole")}
+	want := "iam:PassRole: pass-role [resource: arn:aws:iam::012345678901:role/app], mfa (inline) (group:developers) [conditional]"
+	if got := perm.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if !perm.IsRestricted() {
+		t.Error("IsRestricted() = false, want true")
+	}
+}
@@ -0,0 +1,142 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// getExclusionStatements returns Deny statements made from the exclusion rules of Action/Resource.
+// The excluded permissions are handled as same as the permissions denied in the policy.
+func (c *Config) getExclusionStatements() []Statement {
+	var result []Statement
+	if list := c.GetExcludeActions(); len(list) != 0 {
+		actions := make([]string, len(list))
+		for i, v := range list {
+			actions[i] = toActionPattern(v)
+		}
+		result = append(result, Statement{
+			Effect:   effectDeny,
+			Action:   actions,
+			Resource: []string{"*"},
+		})
+	}
+	if list := c.GetExcludeResources(); len(list) != 0 {
+		resources := make([]string, len(list))
+		for i, v := range list {
+			resources[i] = toWildcardPattern(v)
+		}
+		result = append(result, Statement{
+			Effect:   effectDeny,
+			Action:   []string{"*"},
+			Resource: resources,
+		})
+	}
+	return result
+}
+
+// hasExclusionRule checks if config has exclusion rules for Action/Resource.
+func (c *Config) hasExclusionRule() bool {
+	return len(c.GetExcludeActions()) != 0 || len(c.GetExcludeResources()) != 0
+}
+
+// isExcludedEntity checks if the User/Group/Role is excluded. (e.g. `AWSServiceRoleFor*`, `role/AWSServiceRoleFor*`)
+func (c *Config) isExcludedEntity(typ, name string) bool {
+	return matchNamePatterns(c.GetExcludeEntities(), name, typ+"/"+name)
+}
+
+// isExcludedPolicy checks if the policy is excluded by the name or ARN. (e.g. `AWSServiceRole*`)
+func (c *Config) isExcludedPolicy(name, arn string) bool {
+	return matchNamePatterns(c.GetExcludePolicies(), name, arn)
+}
+
+// isExcludedStatement checks if all of the actions in the Allow statement are excluded.
+func isExcludedStatement(s Statement, excludes []Statement) bool {
+	if len(s.NotAction) != 0 {
+		return isDeniedAction(s, "*", excludes)
+	}
+	for _, a := range s.Action {
+		if !isDeniedAction(s, a, excludes) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNamePatterns checks if any of the values matches the patterns.
+// The pattern without wildcard matches as substring, and it's case-insensitive.
+func matchNamePatterns(patterns []string, values ...string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(toWildcardPattern(p))
+		for _, v := range values {
+			if v != "" && matchWildcard(p, strings.ToLower(v)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterUsers removes the excluded users.
+func (c *PolicyChecker) filterUsers(list []iam.User) []iam.User {
+	result := make([]iam.User, 0, len(list))
+	for _, v := range list {
+		if !c.config.isExcludedEntity(entityUser, v.UserName) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// filterGroups removes the excluded groups.
+func (c *PolicyChecker) filterGroups(list []iam.Group) []iam.Group {
+	result := make([]iam.Group, 0, len(list))
+	for _, v := range list {
+		if !c.config.isExcludedEntity(entityGroup, v.GroupName) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// filterRoles removes the excluded roles.
+func (c *PolicyChecker) filterRoles(list []iam.Role) []iam.Role {
+	result := make([]iam.Role, 0, len(list))
+	for _, v := range list {
+		if !c.config.isExcludedEntity(entityRole, v.RoleName) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// filterPolicies removes the excluded managed policies.
+func (c *PolicyChecker) filterPolicies(list []iam.Policy) []iam.Policy {
+	result := make([]iam.Policy, 0, len(list))
+	for _, v := range list {
+		if !c.config.isExcludedPolicy(v.PolicyName, v.ARN) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// filterEntities removes the excluded entities of the managed policy.
+func (c *PolicyChecker) filterEntities(list []iam.PolicyEntity) []iam.PolicyEntity {
+	result := make([]iam.PolicyEntity, 0, len(list))
+	for _, e := range list {
+		var typ string
+		switch {
+		case e.IsUser():
+			typ = entityUser
+		case e.IsGroup():
+			typ = entityGroup
+		case e.IsRole():
+			typ = entityRole
+		}
+		if !c.config.isExcludedEntity(typ, e.Name) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package checker
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMatchNamePatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		values   []string
+		want     bool
+	}{
+		{[]string{"admin"}, []string{"admin"}, true},
+		{[]string{"admin"}, []string{"site-admin"}, true},
+		{[]string{"ADMIN"}, []string{"Admin"}, true},
+		{[]string{"role/AWSServiceRoleFor*"}, []string{"AWSServiceRoleForSupport", "role/AWSServiceRoleForSupport"}, true},
+		{[]string{"role/AWSServiceRoleFor*"}, []string{"AWSServiceRoleForSupport", "user/AWSServiceRoleForSupport"}, false},
+		{[]string{"AWSServiceRole*"}, []string{"AWSSupportServiceRolePolicy", "arn:aws:iam::aws:policy/aws-service-role/AWSSupportServiceRolePolicy"}, false},
+		{[]string{"arn:aws:iam::aws:policy/*"}, []string{"ReadOnlyAccess", "arn:aws:iam::aws:policy/ReadOnlyAccess"}, true},
+		{[]string{"dev", "oper"}, []string{"operators"}, true},
+		{nil, []string{"admin"}, false},
+		{[]string{"admin"}, []string{""}, false},
+	}
+	for _, tt := range tests {
+		if got := matchNamePatterns(tt.patterns, tt.values...); got != tt.want {
+			t.Errorf("matchNamePatterns(%q, %q) = %v, want %v", tt.patterns, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestCollectPoliciesExclusion(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludeEntity string
+		excludePolicy string
+		want          string
+	}{
+		{
+			name: "no exclusion",
+			want: "AWSSupportServiceRolePolicy:[role/AWSServiceRoleForSupport] AuditAccess:[user/bob role/partner-audit] DeveloperAccess:[group/developers group/operators role/app]",
+		},
+		{
+			name:          "excluded user",
+			excludeEntity: "user/bob",
+			want:          "AWSSupportServiceRolePolicy:[role/AWSServiceRoleForSupport] AuditAccess:[role/partner-audit] DeveloperAccess:[group/developers group/operators role/app]",
+		},
+		{
+			name:          "policy of excluded entities",
+			excludeEntity: "role/AWSServiceRoleFor*",
+			want:          "AuditAccess:[user/bob role/partner-audit] DeveloperAccess:[group/developers group/operators role/app]",
+		},
+		{
+			name:          "excluded policy",
+			excludePolicy: "AWSSupport* arn:aws:iam::012345678901:policy/AuditAccess",
+			want:          "DeveloperAccess:[group/developers group/operators role/app]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestChecker(t, Config{ShowAllPolicy: true, ExcludeEntity: tt.excludeEntity, ExcludePolicy: tt.excludePolicy})
+			list, err := c.CollectPolicies()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatPolicyEntities(list); got != tt.want {
+				t.Errorf("CollectPolicies() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectPoliciesExcludedGroupUser(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true, ExcludeEntity: "user/alice"})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := findPolicy(list, "DeveloperAccess")
+	if p == nil {
+		t.Fatal("DeveloperAccess is not in the results")
+	}
+	if got := strings.Join(p.AttachedAllUsers, " "); got != "bob ci-bot" {
+		t.Errorf("AttachedAllUsers = %q, want %q", got, "bob ci-bot")
+	}
+}
+
+func TestCollectInlinePoliciesExclusion(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true, ExcludeEntity: "ci-bot role/AWSServiceRoleFor*", ExcludePolicy: "restart-*"})
+	list, err := c.CollectInlinePolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatPolicyEntities(list); got != "sns-publish:[user/alice]" {
+		t.Errorf("CollectInlinePolicies() = %q, want %q", got, "sns-publish:[user/alice]")
+	}
+}
+
+// TestCollectPoliciesParallel checks the results in parallel are same as the sequential ones.
+// Run with `-race` to check the config is not written by the workers.
+func TestCollectPoliciesParallel(t *testing.T) {
+	conf := Config{
+		ShowAllPolicy: true,
+		AllVersions:   true,
+		ExcludeEntity: "user/alice role/AWSServiceRoleFor*",
+		ExcludePolicy: "AuditAccess",
+		ExcludeAction: "iam:PassRole",
+	}
+	c, _ := newTestChecker(t, conf)
+	want, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantInline, err := c.CollectInlinePolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Parallel = 8
+	c, _ = newTestChecker(t, conf)
+	for i := 0; i < 5; i++ {
+		got, err := c.CollectPolicies()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("CollectPolicies() = %q, want %q", formatPolicyEntities(got), formatPolicyEntities(want))
+		}
+		gotInline, err := c.CollectInlinePolicies()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gotInline, wantInline) {
+			t.Errorf("CollectInlinePolicies() = %q, want %q", formatPolicyEntities(gotInline), formatPolicyEntities(wantInline))
+		}
+	}
+}
+
+// formatPolicyEntities returns `<policy>:[<entities>]` of the policies sorted by the name.
+func formatPolicyEntities(list []*AwsPolicy) string {
+	result := make([]string, len(list))
+	for i, p := range list {
+		var entities []string
+		for _, v := range p.AttachedUsers {
+			entities = append(entities, entityUser+"/"+v)
+		}
+		for _, v := range p.AttachedGroups {
+			entities = append(entities, entityGroup+"/"+v.Name)
+		}
+		for _, v := range p.AttachedRoles {
+			entities = append(entities, entityRole+"/"+v)
+		}
+		result[i] = p.PolicyName + ":[" + strings.Join(entities, " ") + "]"
+	}
+	sort.Strings(result)
+	return strings.Join(result, " ")
+}
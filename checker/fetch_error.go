@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FetchError is a failed call which makes the item skipped or incomplete in the results.
+// (e.g. a policy is missing when GetPolicyVersion fails, and members of a group are missing when GetGroup fails)
+type FetchError struct {
+	Func       string `json:"func"`
+	Error      string `json:"error"`
+	Entity     string `json:"entity,omitempty"` // `<type>/<name>` of User/Group/Role (e.g. `group/developers`)
+	PolicyARN  string `json:"policy_arn,omitempty"`
+	PolicyName string `json:"policy_name,omitempty"`
+	VersionID  string `json:"version_id,omitempty"`
+}
+
+// String returns the error as the log message. (e.g. `Func:[GetGroup] Error:[AccessDenied], Entity:[group/developers]`)
+func (e FetchError) String() string {
+	msg := fmt.Sprintf("Func:[%s] Error:[%s]", e.Func, e.Error)
+	params := []struct {
+		key   string
+		value string
+	}{
+		{"Entity", e.Entity},
+		{"ARN", e.PolicyARN},
+		{"PolicyName", e.PolicyName},
+		{"VersionID", e.VersionID},
+	}
+	for _, p := range params {
+		if p.value != "" {
+			msg += fmt.Sprintf(", %s:[%s]", p.key, p.value)
+		}
+	}
+	return msg
+}
+
+// PartialDataError is returned in strict mode when any item is skipped by FetchError.
+// The results are saved before the error is returned.
+type PartialDataError struct {
+	Errors []FetchError
+}
+
+func (e *PartialDataError) Error() string {
+	return fmt.Sprintf("%d items were skipped or incomplete by errors; the results are partial", len(e.Errors))
+}
+
+// addFetchError logs the failed call and collects it.
+func (c *PolicyChecker) addFetchError(e FetchError) {
+	c.loggingError("%s", e.String())
+
+	c.fetchErrorsMu.Lock()
+	defer c.fetchErrorsMu.Unlock()
+	c.fetchErrors = append(c.fetchErrors, e)
+}
+
+// FetchErrors returns the failed calls collected since the PolicyChecker is created.
+// The items in the errors are skipped or incomplete in the results of Collect* methods.
+func (c *PolicyChecker) FetchErrors() []FetchError {
+	c.fetchErrorsMu.Lock()
+	defer c.fetchErrorsMu.Unlock()
+	return append([]FetchError{}, c.fetchErrors...)
+}
+
+// SaveFetchErrors saves the failed calls to the error output file.
+func (c *PolicyChecker) SaveFetchErrors(list []FetchError) error {
+	c.loggingInfo("invoking `SaveFetchErrors` size:[%d] ...", len(list))
+
+	f, err := NewFileHandlerWithFormat(c.config.GetErrorOutputFile(), c.config.OutputFormat)
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"func",
+		"error",
+		"entity",
+		"policy_arn",
+		"policy_name",
+		"version_id",
+	}
+
+	lines := make([][]string, 0, len(list))
+	for _, e := range list {
+		lines = append(lines, []string{
+			e.Func,
+			e.Error,
+			e.Entity,
+			e.PolicyARN,
+			e.PolicyName,
+			e.VersionID,
+		})
+	}
+	return f.WriteAll(headers, lines)
+}
+
+// saveFetchErrors saves the failed calls to the error output file after the results are saved.
+// It returns the failed calls, and the file is not created when there is no error.
+func (c *PolicyChecker) saveFetchErrors() ([]FetchError, error) {
+	list := c.FetchErrors()
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	if err := c.SaveFetchErrors(list); err != nil {
+		return nil, err
+	}
+	c.loggingWarn("%d items were skipped or incomplete by errors, see '%s'", len(list), c.config.GetErrorOutputFile())
+	return list, nil
+}
+
+// toErrorOutputFile returns the error output file next to the output file. (e.g. `policy.csv` to `policy.errors.csv`)
+func toErrorOutputFile(file string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + ".errors" + ext
+}
@@ -0,0 +1,207 @@
+package checker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	SDK "github.com/aws/aws-sdk-go/service/iam"
+)
+
+// failingClient returns AccessDenied for GetPolicyVersion of the policy and GetGroup of the group.
+type failingClient struct {
+	IAMClient
+	policyARN string
+	groupName string
+}
+
+func (c *failingClient) GetPolicyVersion(arn, versionID string) (*SDK.PolicyVersion, error) {
+	if arn == c.policyARN {
+		return nil, awserr.New("AccessDenied", "not authorized to perform: iam:GetPolicyVersion", nil)
+	}
+	return c.IAMClient.GetPolicyVersion(arn, versionID)
+}
+
+func (c *failingClient) GetGroup(groupName string) (*SDK.GetGroupOutput, error) {
+	if groupName == c.groupName {
+		return nil, awserr.New("AccessDenied", "not authorized to perform: iam:GetGroup", nil)
+	}
+	return c.IAMClient.GetGroup(groupName)
+}
+
+// newFailingChecker returns *PolicyChecker which fails to fetch AuditAccess and the members of operators.
+func newFailingChecker(t *testing.T, conf Config) *PolicyChecker {
+	t.Helper()
+	snap, err := NewSnapshotClient(testSnapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := NewStdLogger(&strings.Builder{}, "error", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Logger = logger
+	cli := &failingClient{
+		IAMClient: snap,
+		policyARN: "arn:aws:iam::012345678901:policy/AuditAccess",
+		groupName: "operators",
+	}
+	c, err := NewWithClient(conf, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCheckPoliciesFetchErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		strict     bool
+		wantErrors string
+	}{
+		{"csv", "policy.csv", false, "policy.errors.csv"},
+		{"json", "policy.json", false, "policy.errors.json"},
+		{"strict", "policy.csv", true, "policy.errors.csv"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := newFailingChecker(t, Config{
+				ShowAllPolicy: true,
+				OutputFile:    filepath.Join(dir, tt.output),
+				Strict:        tt.strict,
+			})
+
+			err := c.CheckPolicies()
+			var partialErr *PartialDataError
+			switch {
+			case !tt.strict && err != nil:
+				t.Fatalf("CheckPolicies() error = %v", err)
+			case tt.strict && !errors.As(err, &partialErr):
+				t.Fatalf("CheckPolicies() error = %v, want *PartialDataError", err)
+			case tt.strict && len(partialErr.Errors) != 2:
+				t.Errorf("PartialDataError.Errors = %+v, want 2 errors", partialErr.Errors)
+			}
+
+			// the results are saved in strict mode too.
+			if _, err := os.Stat(filepath.Join(dir, tt.output)); err != nil {
+				t.Error(err)
+			}
+			byt, err := os.ReadFile(filepath.Join(dir, tt.wantErrors))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range []string{"GetPolicyVersion", "arn:aws:iam::012345678901:policy/AuditAccess", "GetGroup", "group/operators", "AccessDenied"} {
+				if !strings.Contains(string(byt), v) {
+					t.Errorf("%s does not contain %q: %s", tt.wantErrors, v, byt)
+				}
+			}
+
+			s := c.Summary()
+			if s == nil {
+				t.Fatal("Summary() = nil")
+			}
+			wantStatus := CheckStatusOK
+			if tt.strict {
+				wantStatus = CheckStatusPartial
+			}
+			if s.Skipped != 2 || s.Status != wantStatus {
+				t.Errorf("Summary() = {skipped:%d status:%s}, want {skipped:2 status:%s}", s.Skipped, s.Status, wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckPoliciesFetchErrorsSkippedItems(t *testing.T) {
+	c := newFailingChecker(t, Config{ShowAllPolicy: true})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := findPolicy(list, "AuditAccess"); p != nil {
+		t.Error("AuditAccess is in the results")
+	}
+	// the members of operators are missing.
+	p := findPolicy(list, "DeveloperAccess")
+	if p == nil {
+		t.Fatal("DeveloperAccess is not in the results")
+	}
+	if got := strings.Join(p.AttachedAllUsers, " "); got != "alice bob" {
+		t.Errorf("AttachedAllUsers = %q, want %q", got, "alice bob")
+	}
+
+	errs := c.FetchErrors()
+	if len(errs) != 2 {
+		t.Fatalf("FetchErrors() = %+v, want 2 errors", errs)
+	}
+	// FetchErrors returns a copy.
+	errs[0].Func = "changed"
+	if c.FetchErrors()[0].Func == "changed" {
+		t.Error("FetchErrors() returns the internal slice")
+	}
+}
+
+func TestCheckPoliciesWithoutFetchErrors(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true, OutputFile: filepath.Join(dir, "policy.csv"), Strict: true})
+	if err := c.CheckPolicies(); err != nil {
+		t.Fatalf("CheckPolicies() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "policy.errors.csv")); !os.IsNotExist(err) {
+		t.Errorf("error output file is created: %v", err)
+	}
+	if s := c.Summary(); s.Skipped != 0 || s.Status != CheckStatusOK {
+		t.Errorf("Summary() = {skipped:%d status:%s}", s.Skipped, s.Status)
+	}
+}
+
+func TestFetchErrorString(t *testing.T) {
+	tests := []struct {
+		err  FetchError
+		want string
+	}{
+		{
+			FetchError{Func: "ListRoles", Error: "AccessDenied"},
+			"Func:[ListRoles] Error:[AccessDenied]",
+		},
+		{
+			FetchError{Func: "GetGroup", Error: "AccessDenied", Entity: "group/developers"},
+			"Func:[GetGroup] Error:[AccessDenied], Entity:[group/developers]",
+		},
+		{
+			FetchError{Func: "GetPolicyVersion", Error: "NoSuchEntity", PolicyARN: "arn:aws:iam::aws:policy/ReadOnlyAccess", VersionID: "v2"},
+			"Func:[GetPolicyVersion] Error:[NoSuchEntity], ARN:[arn:aws:iam::aws:policy/ReadOnlyAccess], VersionID:[v2]",
+		},
+		{
+			FetchError{Func: "GetUserPolicy", Error: "AccessDenied", Entity: "user/alice", PolicyName: "inline"},
+			"Func:[GetUserPolicy] Error:[AccessDenied], Entity:[user/alice], PolicyName:[inline]",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.err.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestToErrorOutputFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"policy.csv", "policy.errors.csv"},
+		{"out/audit.json", "out/audit.errors.json"},
+		{"result.v1.tsv", "result.v1.errors.tsv"},
+		{"output", "output.errors"},
+	}
+	for _, tt := range tests {
+		if got := toErrorOutputFile(tt.file); got != tt.want {
+			t.Errorf("toErrorOutputFile(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
@@ -2,19 +2,44 @@ package checker
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
-// FileHandler handles CSV file.
+// output formats
+const (
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// FileHandler handles CSV/TSV/JSON/NDJSON file.
 type FileHandler struct {
 	separator rune
+	format    string
 	fp        *os.File
 }
 
 // NewFileHandler returns initialized *FileHandler
+// The format is decided by the file extension.
 func NewFileHandler(file string) (*FileHandler, error) {
+	return NewFileHandlerWithFormat(file, "")
+}
+
+// NewFileHandlerWithFormat returns initialized *FileHandler with the format.
+// When the format is empty, it's decided by the file extension.
+func NewFileHandlerWithFormat(file, format string) (*FileHandler, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = getFormatFromFileName(file)
+	}
+	if err := validateFormat(format); err != nil {
+		return nil, err
+	}
 	if err := checkIsDir(file); err != nil {
 		return nil, err
 	}
@@ -26,17 +51,23 @@ func NewFileHandler(file string) (*FileHandler, error) {
 	}
 
 	f := &FileHandler{
-		fp: fp,
+		fp:     fp,
+		format: format,
 	}
 
-	switch filepath.Ext(file) {
-	case ".tsv":
+	switch format {
+	case FormatTSV:
 		f.separator = '\t'
 	}
 
 	return f, nil
 }
 
+// IsJSON checks if the output format is JSON or NDJSON.
+func (f *FileHandler) IsJSON() bool {
+	return f.format == FormatJSON || f.format == FormatNDJSON
+}
+
 // WriteAll writes lines into file
 func (f *FileHandler) WriteAll(header []string, lines [][]string) error {
 	defer f.fp.Close()
@@ -56,6 +87,51 @@ func (f *FileHandler) WriteAll(header []string, lines [][]string) error {
 	return nil
 }
 
+// WriteObjects writes objects into file as JSON array or NDJSON.
+func (f *FileHandler) WriteObjects(list []interface{}) error {
+	defer f.fp.Close()
+
+	if f.format == FormatNDJSON {
+		enc := json.NewEncoder(f.fp)
+		for _, v := range list {
+			if err := enc.Encode(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if list == nil {
+		list = []interface{}{}
+	}
+	enc := json.NewEncoder(f.fp)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
+
+// getFormatFromFileName returns output format from the file extension.
+func getFormatFromFileName(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".tsv":
+		return FormatTSV
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	default:
+		return FormatCSV
+	}
+}
+
+// validateFormat checks if the output format is supported.
+func validateFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatTSV, FormatJSON, FormatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported output format: '%s'", format)
+}
+
 // checkIsDir checks if the given file path is directory.
 func checkIsDir(filePath string) error {
 	info, err := os.Stat(filePath)
@@ -0,0 +1,351 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// keys of the filter expression.
+const (
+	filterKeyService   = "service"
+	filterKeyAction    = "action"
+	filterKeyResource  = "resource"
+	filterKeyAccess    = "access"
+	filterKeyCondition = "condition"
+)
+
+// FilterExpression is a filter evaluated per statement.
+// (e.g. `service:s3 AND action:Put* AND resource:arn:aws:s3:::prod-*`)
+//
+//   - `service:<service>` matches the statement granting any action of the service.
+//   - `action:<action>` matches the statement granting the action. The action without service prefix matches the action name. (e.g. `action:Put*` matches `s3:PutObject`)
+//   - `resource:<resource>` matches the statement granting the resource.
+//   - `access:<access level>` matches the statement granting any action with the access level in the action catalog.
+//   - `condition:<condition key>` matches the statement having the condition key.
+//   - `AND`, `OR`, `NOT` and parentheses combine the filters. Adjacent filters without operator are combined by `AND`.
+type FilterExpression interface {
+	// Match checks if the Allow statement matches the filter.
+	// denies are Deny statements in the same policy.
+	Match(c Config, s Statement, denies []Statement) bool
+	String() string
+}
+
+// ParseFilter parses the filter expression.
+func ParseFilter(expr string) (FilterExpression, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("filter is empty")
+	}
+
+	p := &filterParser{tokens: tokens}
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isEnd() {
+		return nil, fmt.Errorf("unexpected token in filter: '%s'", p.peek())
+	}
+	return result, nil
+}
+
+type filterAnd []FilterExpression
+
+func (f filterAnd) Match(c Config, s Statement, denies []Statement) bool {
+	for _, v := range f {
+		if !v.Match(c, s, denies) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f filterAnd) String() string {
+	return joinFilters(f, " AND ")
+}
+
+type filterOr []FilterExpression
+
+func (f filterOr) Match(c Config, s Statement, denies []Statement) bool {
+	for _, v := range f {
+		if v.Match(c, s, denies) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f filterOr) String() string {
+	return joinFilters(f, " OR ")
+}
+
+type filterNot struct {
+	expr FilterExpression
+}
+
+func (f filterNot) Match(c Config, s Statement, denies []Statement) bool {
+	return !f.expr.Match(c, s, denies)
+}
+
+func (f filterNot) String() string {
+	return "NOT " + joinFilters([]FilterExpression{f.expr}, "")
+}
+
+type filterTerm struct {
+	key   string
+	value string
+}
+
+func newFilterTerm(token string) (filterTerm, error) {
+	parts := strings.SplitN(token, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return filterTerm{}, fmt.Errorf("filter must be '<key>:<value>': '%s'", token)
+	}
+
+	t := filterTerm{
+		key:   strings.ToLower(parts[0]),
+		value: strings.Trim(parts[1], `"`),
+	}
+	switch t.key {
+	case filterKeyService, filterKeyAction, filterKeyResource, filterKeyCondition:
+	case filterKeyAccess:
+		level, err := normalizeAccessLevel(t.value)
+		if err != nil {
+			return filterTerm{}, err
+		}
+		t.value = level
+	default:
+		return filterTerm{}, fmt.Errorf("unknown filter key: '%s'", parts[0])
+	}
+	return t, nil
+}
+
+func (f filterTerm) Match(c Config, s Statement, denies []Statement) bool {
+	useWildcard := c.MatchStatementWildcard
+	switch f.key {
+	case filterKeyService:
+		svc := newTargetService([]string{f.value})
+		svc.useStatementWildcard = useWildcard
+		return hasAllowedAction(s, svc.grantedActions(s), denies)
+	case filterKeyAction:
+		pattern := toFilterActionPattern(f.value)
+		if hasAllowedAction(s, grantedActions(s, []string{pattern}, useWildcard), denies) {
+			return true
+		}
+		if !useWildcard {
+			return false
+		}
+		// the wildcard in the statement is resolved by the action catalog. (e.g. `s3:*` grants `action:Put*`)
+		catalog, _ := c.GetActionCatalog()
+		pattern = strings.ToLower(pattern)
+		for _, e := range catalog.grantedActions(s, denies, nil) {
+			if matchWildcard(pattern, e.lower) {
+				return true
+			}
+		}
+		return false
+	case filterKeyResource:
+		return hasAllowedResource(s, grantedResources(s, []string{f.value}, useWildcard), denies)
+	case filterKeyAccess:
+		catalog, _ := c.GetActionCatalog()
+		return len(catalog.grantedActions(s, denies, []string{f.value})) != 0
+	case filterKeyCondition:
+		return s.Condition.HasKey(f.value)
+	}
+	return false
+}
+
+func (f filterTerm) String() string {
+	return f.key + ":" + f.value
+}
+
+// getFilterTerms returns `<key>:<value>` filters in the filter expression.
+func getFilterTerms(f FilterExpression) []filterTerm {
+	switch v := f.(type) {
+	case filterTerm:
+		return []filterTerm{v}
+	case filterNot:
+		return getFilterTerms(v.expr)
+	case filterAnd:
+		var result []filterTerm
+		for _, e := range v {
+			result = append(result, getFilterTerms(e)...)
+		}
+		return result
+	case filterOr:
+		var result []filterTerm
+		for _, e := range v {
+			result = append(result, getFilterTerms(e)...)
+		}
+		return result
+	}
+	return nil
+}
+
+// toFilterActionPattern converts the action in the filter into glob pattern.
+// The action without service prefix is matched with the action name. (e.g. `Put*` to `*:Put*`)
+func toFilterActionPattern(action string) string {
+	if !strings.Contains(action, ":") && hasWildcard(action) {
+		return "*:" + action
+	}
+	return toActionPattern(action)
+}
+
+func joinFilters(list []FilterExpression, sep string) string {
+	result := make([]string, len(list))
+	for i, v := range list {
+		result[i] = v.String()
+		switch v.(type) {
+		case filterAnd, filterOr:
+			result[i] = "(" + result[i] + ")"
+		}
+	}
+	return strings.Join(result, sep)
+}
+
+// filterParser is a recursive descent parser of the filter expression.
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) parseOr() (FilterExpression, error) {
+	var list filterOr
+	for {
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+		if !p.consume("OR") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *filterParser) parseAnd() (FilterExpression, error) {
+	var list filterAnd
+	for {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+
+		// adjacent filters without operator are combined by AND.
+		if !p.consume("AND") && (p.isEnd() || p.peek() == ")" || strings.EqualFold(p.peek(), "OR")) {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *filterParser) parseNot() (FilterExpression, error) {
+	if p.consume("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (FilterExpression, error) {
+	if p.isEnd() {
+		return nil, errors.New("unexpected end of filter")
+	}
+
+	token := p.next()
+	switch {
+	case token == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, errors.New("missing ')' in filter")
+		}
+		return expr, nil
+	case token == ")", isFilterOperator(token):
+		return nil, fmt.Errorf("unexpected token in filter: '%s'", token)
+	}
+	return newFilterTerm(token)
+}
+
+func (p *filterParser) isEnd() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() string {
+	return p.tokens[p.pos]
+}
+
+func (p *filterParser) next() string {
+	v := p.tokens[p.pos]
+	p.pos++
+	return v
+}
+
+// consume skips the token when it's the given operator or parenthesis.
+func (p *filterParser) consume(token string) bool {
+	if p.isEnd() || !strings.EqualFold(p.peek(), token) {
+		return false
+	}
+	p.pos++
+	return true
+}
+
+func isFilterOperator(token string) bool {
+	switch strings.ToUpper(token) {
+	case "AND", "OR", "NOT":
+		return true
+	}
+	return false
+}
+
+// tokenizeFilter splits the filter expression into parentheses, operators and `<key>:<value>` filters.
+// The value can be quoted by `"` to contain spaces or parentheses.
+func tokenizeFilter(expr string) ([]string, error) {
+	var tokens []string
+	var buf strings.Builder
+	inQuote := false
+	flush := func() {
+		if buf.Len() != 0 {
+			tokens = append(tokens, buf.String())
+			buf.Reset()
+		}
+	}
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			buf.WriteRune(r)
+		case inQuote:
+			buf.WriteRune(r)
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, errors.New("missing '\"' in filter")
+	}
+	flush()
+	return tokens, nil
+}
@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"testing"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string
+	}{
+		{"single term", "service:s3", "service:s3"},
+		{"AND", "service:s3 AND action:Put*", "service:s3 AND action:Put*"},
+		{"adjacent terms are AND", "service:s3 action:Put*", "service:s3 AND action:Put*"},
+		{"operators are case-insensitive", "service:s3 and not action:Get* or service:kms", "(service:s3 AND NOT action:Get*) OR service:kms"},
+		{"AND binds tighter than OR", "service:kms OR service:s3 AND action:Put*", "service:kms OR (service:s3 AND action:Put*)"},
+		{"AND binds tighter than OR on the left", "service:s3 AND action:Put* OR service:kms", "(service:s3 AND action:Put*) OR service:kms"},
+		{"NOT binds tighter than AND", "NOT service:s3 AND action:Put*", "NOT service:s3 AND action:Put*"},
+		{"NOT of group", "NOT (service:s3 OR service:kms)", "NOT (service:s3 OR service:kms)"},
+		{"double NOT", "NOT NOT service:s3", "NOT NOT service:s3"},
+		{"parentheses override precedence", "(service:kms OR service:s3) AND action:Put*", "(service:kms OR service:s3) AND action:Put*"},
+		{"nested parentheses", "((service:s3))", "service:s3"},
+		{"adjacent group is AND", "(service:s3 OR service:kms) access:write", "(service:s3 OR service:kms) AND access:Write"},
+		{"value with colons", "resource:arn:aws:s3:::prod-*", "resource:arn:aws:s3:::prod-*"},
+		{"quoted value", `resource:"arn:aws:s3:::my bucket (prod)/*"`, "resource:arn:aws:s3:::my bucket (prod)/*"},
+		{"key is case-insensitive", "Service:s3", "service:s3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) error: %v", tt.expr, err)
+			}
+			if got := f.String(); got != tt.want {
+				t.Errorf("ParseFilter(%q) = %q, want %q", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFilterError(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"empty", ""},
+		{"only spaces", "   "},
+		{"only operator", "AND"},
+		{"only NOT", "NOT"},
+		{"trailing AND", "service:s3 AND"},
+		{"trailing OR", "service:s3 OR"},
+		{"leading AND", "AND service:s3"},
+		{"double OR", "service:s3 OR OR action:Put*"},
+		{"NOT before operator", "service:s3 AND NOT OR service:kms"},
+		{"unclosed parenthesis", "(service:s3"},
+		{"unopened parenthesis", "service:s3)"},
+		{"empty parentheses", "()"},
+		{"reversed parentheses", ")("},
+		{"term without key", "s3"},
+		{"term without value", "service:"},
+		{"unknown key", "foo:bar"},
+		{"unknown access level", "access:foo"},
+		{"unclosed quote", `resource:"arn:aws:s3:::a`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if err == nil {
+				t.Errorf("ParseFilter(%q) = %q, want error", tt.expr, f.String())
+			}
+		})
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	const (
+		putProd = `{"Statement":[{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::prod-a/*"}]}`
+		getDev  = `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::dev-a/*"}]}`
+		withIP  = `{"Statement":[{"Effect":"Allow","Action":"s3:PutObject","Resource":"*","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`
+		s3All   = `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`
+		iamAll  = `{"Statement":[{"Effect":"Allow","Action":"iam:AttachRolePolicy","Resource":"*"}]}`
+	)
+
+	tests := []struct {
+		name     string
+		filter   string
+		wildcard bool
+		doc      string
+		want     bool
+	}{
+		{"service", "service:s3", false, putProd, true},
+		{"other service", "service:kms", false, putProd, false},
+		{"action with prefix", "action:s3:PutObject", false, putProd, true},
+		{"action name without prefix", "action:Put*", false, putProd, true},
+		{"action name does not match", "action:Get*", false, putProd, false},
+		{"resource", "resource:arn:aws:s3:::prod-*", false, putProd, true},
+		{"resource does not match", "resource:arn:aws:s3:::prod-*", false, getDev, false},
+		{"access level", "access:permissions-management", false, iamAll, true},
+		{"condition", "condition:aws:SourceIp", false, withIP, true},
+		{"missing condition", "condition:aws:SourceIp", false, putProd, false},
+
+		{"AND", "service:s3 AND action:Put*", false, putProd, true},
+		{"AND with false term", "service:s3 AND action:Get*", false, putProd, false},
+		{"OR", "action:Get* OR action:Put*", false, putProd, true},
+		{"OR with false terms", "action:Get* OR service:kms", false, putProd, false},
+		{"AND before OR", "service:kms OR service:s3 AND action:Get*", false, putProd, false},
+		{"parentheses before AND", "(service:kms OR service:s3) AND action:Put*", false, putProd, true},
+		{"NOT", "NOT service:kms", false, putProd, true},
+		{"NOT of true term", "NOT service:s3", false, putProd, false},
+		{"NOT condition", "service:s3 AND NOT condition:aws:SourceIp", false, withIP, false},
+		{"NOT condition without condition", "service:s3 AND NOT condition:aws:SourceIp", false, putProd, true},
+		{"NOT group", "NOT (action:Get* OR service:kms)", false, putProd, true},
+
+		{"statement wildcard without --wildcard", "action:Put*", false, s3All, false},
+		{"statement wildcard with --wildcard", "action:Put*", true, s3All, true},
+		{"statement wildcard for other service", "action:iam:Put*", true, s3All, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Config{Filter: tt.filter, MatchStatementWildcard: tt.wildcard}
+			if got := matchDocument(t, conf, tt.doc); got != tt.want {
+				t.Errorf("filter %q = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterMatchPerStatement(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		doc    string
+		want   bool
+	}{
+		{
+			name:   "terms matched by different statements",
+			filter: "action:Put* AND resource:arn:aws:s3:::prod-*",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::dev-a/*"},
+				{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::prod-a/*"}
+			]}`,
+			want: false,
+		},
+		{
+			name:   "terms matched by one of the statements",
+			filter: "action:Put* AND resource:arn:aws:s3:::prod-*",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::dev-a/*"},
+				{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::prod-a/*"}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "NOT is evaluated per statement",
+			filter: "service:s3 AND NOT condition:aws:SourceIp",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:PutObject","Resource":"*","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}},
+				{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "Deny statement does not match",
+			filter: "action:s3:DeleteBucket",
+			doc:    `{"Statement":[{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			want:   false,
+		},
+		{
+			name:   "action denied in the same policy",
+			filter: "action:s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:DeleteBucket","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:Delete*","Resource":"*"}
+			]}`,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchDocument(t, Config{Filter: tt.filter}, tt.doc); got != tt.want {
+				t.Errorf("filter %q = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
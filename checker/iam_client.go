@@ -0,0 +1,162 @@
+package checker
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	SDK "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/evalphobia/aws-sdk-go-wrapper/config"
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// IAMClient is an interface of IAM read operations used in PolicyChecker.
+// *AWSClient, *SnapshotClient and *CachedClient implement this.
+// Implement this to use other data sources (e.g. cache, multiple accounts or fake data for testing).
+type IAMClient interface {
+	// managed policy
+	ListPolicies(scope string, onlyAttached bool) ([]iam.Policy, error)
+	GetPolicyVersion(arn, versionID string) (*SDK.PolicyVersion, error)
+	ListPolicyVersions(arn string) ([]*SDK.PolicyVersion, error)
+	ListEntitiesForPolicy(arn string) ([]iam.PolicyEntity, error)
+	GetGroup(groupName string) (*SDK.GetGroupOutput, error)
+
+	// inline policy
+	ListUsers() ([]iam.User, error)
+	ListUserPolicies(userName string) ([]string, error)
+	GetUserPolicyDocument(userName, policyName string) (*PolicyDocument, error)
+	ListGroups() ([]iam.Group, error)
+	ListGroupPolicies(groupName string) ([]string, error)
+	GetGroupPolicyDocument(groupName, policyName string) (*PolicyDocument, error)
+	ListRoles() ([]iam.Role, error)
+	ListRolePolicies(roleName string) ([]string, error)
+	GetRolePolicyDocument(roleName, policyName string) (*PolicyDocument, error)
+}
+
+var (
+	_ IAMClient = &AWSClient{}
+	_ IAMClient = &SnapshotClient{}
+	_ IAMClient = &CachedClient{}
+)
+
+// AWSClient is IAMClient using AWS API.
+// Inline policy documents and roles are fetched by AWS SDK directly to keep NotAction/NotResource and trust policies.
+type AWSClient struct {
+	*iam.IAM
+	sdk *SDK.IAM
+}
+
+// NewAWSClient returns initialized *AWSClient.
+func NewAWSClient(conf config.Config) (*AWSClient, error) {
+	cli, err := iam.New(conf)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := conf.Session()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSClient{
+		IAM: cli,
+		sdk: SDK.New(sess),
+	}, nil
+}
+
+// GetUserPolicyDocument executes GetUserPolicy operation and returns the document.
+func (c *AWSClient) GetUserPolicyDocument(userName, policyName string) (*PolicyDocument, error) {
+	o, err := c.sdk.GetUserPolicy(&SDK.GetUserPolicyInput{
+		UserName:   aws.String(userName),
+		PolicyName: aws.String(policyName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPolicyDocumentFromResponse(o.PolicyDocument)
+}
+
+// GetGroupPolicyDocument executes GetGroupPolicy operation and returns the document.
+func (c *AWSClient) GetGroupPolicyDocument(groupName, policyName string) (*PolicyDocument, error) {
+	o, err := c.sdk.GetGroupPolicy(&SDK.GetGroupPolicyInput{
+		GroupName:  aws.String(groupName),
+		PolicyName: aws.String(policyName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPolicyDocumentFromResponse(o.PolicyDocument)
+}
+
+// GetRolePolicyDocument executes GetRolePolicy operation and returns the document.
+func (c *AWSClient) GetRolePolicyDocument(roleName, policyName string) (*PolicyDocument, error) {
+	o, err := c.sdk.GetRolePolicy(&SDK.GetRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(policyName),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newPolicyDocumentFromResponse(o.PolicyDocument)
+}
+
+// ListPolicies executes ListPolicies operation and returns all of the managed policies in the scope.
+// scope is one of `aws`, `local` or `all`.
+func (c *AWSClient) ListPolicies(scope string, onlyAttached bool) ([]iam.Policy, error) {
+	input := &SDK.ListPoliciesInput{
+		OnlyAttached: aws.Bool(onlyAttached),
+	}
+	switch scope {
+	case PolicyScopeAWS:
+		input.Scope = aws.String(SDK.PolicyScopeTypeAws)
+	case PolicyScopeLocal:
+		input.Scope = aws.String(SDK.PolicyScopeTypeLocal)
+	default:
+		input.Scope = aws.String(SDK.PolicyScopeTypeAll)
+	}
+
+	var list []iam.Policy
+	err := c.sdk.ListPoliciesPages(input, func(o *SDK.ListPoliciesOutput, lastPage bool) bool {
+		list = append(list, iam.NewPolicies(o.Policies)...)
+		return true
+	})
+	return list, err
+}
+
+// ListPolicyVersions executes ListPolicyVersions operation and returns all of the versions of the policy.
+// Document is not contained in the versions.
+func (c *AWSClient) ListPolicyVersions(arn string) ([]*SDK.PolicyVersion, error) {
+	var list []*SDK.PolicyVersion
+	err := c.sdk.ListPolicyVersionsPages(&SDK.ListPolicyVersionsInput{
+		PolicyArn: aws.String(arn),
+	}, func(o *SDK.ListPolicyVersionsOutput, lastPage bool) bool {
+		list = append(list, o.Versions...)
+		return true
+	})
+	return list, err
+}
+
+// ListRoles executes ListRoles operation and returns all of the roles with AssumeRolePolicyDocument.
+func (c *AWSClient) ListRoles() ([]iam.Role, error) {
+	var list []iam.Role
+	err := c.sdk.ListRolesPages(&SDK.ListRolesInput{}, func(o *SDK.ListRolesOutput, lastPage bool) bool {
+		for _, r := range o.Roles {
+			role := iam.NewRole(r)
+			role.AssumeRolePolicyDocument = aws.StringValue(r.AssumeRolePolicyDocument)
+			role.Description = aws.StringValue(r.Description)
+			list = append(list, role)
+		}
+		return true
+	})
+	return list, err
+}
+
+// newPolicyDocumentFromResponse returns *PolicyDocument from URL-encoded document.
+// It returns nil when the document is empty.
+func newPolicyDocumentFromResponse(document *string) (*PolicyDocument, error) {
+	if document == nil {
+		return nil, nil
+	}
+
+	pd, err := NewPolicyDocumentFromDocument(*document)
+	if err != nil {
+		return nil, err
+	}
+	return &pd, nil
+}
@@ -0,0 +1,185 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// issues of actions found by the action catalog.
+const (
+	LintIssueInvalidAction  = "invalid-action"  // the action does not have service prefix (e.g. `GetObject`)
+	LintIssueUnknownService = "unknown-service" // the service is not in the catalog
+	LintIssueUnknownAction  = "unknown-action"  // the action or the pattern does not match any action of the service
+)
+
+// max edit distances of the suggestion for unknown action or service.
+const (
+	maxActionSuggestionDistance  = 2
+	maxServiceSuggestionDistance = 1 // service prefixes are short and similar to each other. (e.g. `sns`, `sqs`, `ssm`)
+)
+
+// LintFinding is an action in the policy which does not exist in the action catalog.
+// A typo in Deny statements does not deny anything, so it's more severe than in Allow statements.
+type LintFinding struct {
+	Severity       Severity   `json:"severity"`
+	Issue          string     `json:"issue"`
+	Policy         *AwsPolicy `json:"policy"`
+	StatementIndex int        `json:"statement_index"`
+	Effect         string     `json:"effect"`
+	Action         string     `json:"action"`
+	Suggestion     string     `json:"suggestion,omitempty"` // similar action or service in the catalog
+}
+
+// lintAction checks if the action exists in the catalog.
+// It returns empty issue for the valid action, and similar name in the catalog for the invalid one if exists.
+func (c *ActionCatalog) lintAction(action string) (issue, suggestion string) {
+	if action == "*" {
+		return "", ""
+	}
+
+	parts := strings.SplitN(action, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return LintIssueInvalidAction, ""
+	}
+
+	svc, name := strings.ToLower(parts[0]), parts[1]
+	switch {
+	case hasWildcard(svc):
+		return "", ""
+	case !c.HasService(svc):
+		return LintIssueUnknownService, c.suggestService(svc)
+	case hasWildcard(name):
+		if len(c.ExpandAction(action)) == 0 {
+			return LintIssueUnknownAction, ""
+		}
+		return "", ""
+	}
+
+	if _, ok := c.GetAction(action); ok {
+		return "", ""
+	}
+	return LintIssueUnknownAction, c.suggestAction(svc, name)
+}
+
+// suggestService returns the service prefix similar to the given one.
+func (c *ActionCatalog) suggestService(svc string) string {
+	best, bestDist := "", maxServiceSuggestionDistance+1
+	for _, s := range c.Services {
+		if d := editDistance(svc, s.Prefix); d < bestDist {
+			best, bestDist = s.Prefix, d
+		}
+	}
+	return best
+}
+
+// suggestAction returns the action of the service similar to the given name.
+func (c *ActionCatalog) suggestAction(svc, name string) string {
+	name = strings.ToLower(name)
+	best, bestDist := "", maxActionSuggestionDistance+1
+	for _, e := range c.entries {
+		if !strings.HasPrefix(e.lower, svc+":") {
+			continue
+		}
+		if d := editDistance(name, strings.ToLower(e.Name)); d < bestDist {
+			best, bestDist = e.name, d
+		}
+	}
+	return best
+}
+
+// lintPolicies checks actions in the statements of the policies by the catalog.
+func lintPolicies(catalog *ActionCatalog, list []*AwsPolicy) []LintFinding {
+	var result []LintFinding
+	for _, p := range list {
+		for i, s := range p.Policy.Statement {
+			actions := append(append([]string{}, s.Action...), s.NotAction...)
+			for _, a := range actions {
+				issue, suggestion := catalog.lintAction(a)
+				if issue == "" {
+					continue
+				}
+				partial := issue == LintIssueUnknownAction && catalog.IsPartialService(strings.SplitN(a, ":", 2)[0])
+				result = append(result, LintFinding{
+					Severity:       getLintSeverity(s, issue, suggestion, partial),
+					Issue:          issue,
+					Policy:         p,
+					StatementIndex: i,
+					Effect:         s.Effect,
+					Action:         a,
+					Suggestion:     suggestion,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// getLintSeverity returns high for Deny statements, because the typo makes a silent gap of the denied permissions.
+// Unknown service and unknown action of the partial service are less severe, because they may not be in the catalog yet.
+func getLintSeverity(s Statement, issue, suggestion string, partial bool) Severity {
+	switch {
+	case !s.IsDeny():
+		return SeverityLow
+	case issue == LintIssueInvalidAction,
+		issue == LintIssueUnknownAction && !partial:
+		return SeverityHigh
+	case suggestion != "":
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// lintTargetRule checks the action rule of config by the catalog.
+// The rule without wildcard is checked as substring (e.g. `Delete`) or prefix (e.g. `s3:Get`) of the actions.
+func (c *ActionCatalog) lintTargetRule(target string) (issue, suggestion string) {
+	if strings.Contains(target, ":") {
+		issue, suggestion = c.lintAction(target)
+		if issue == LintIssueUnknownAction && !hasWildcard(target) && len(c.ExpandAction(toActionPattern(target))) != 0 {
+			return "", ""
+		}
+		return issue, suggestion
+	}
+	if len(c.ExpandAction(toWildcardPattern(target))) == 0 {
+		return LintIssueUnknownAction, ""
+	}
+	return "", ""
+}
+
+// formatLintIssue returns the message of the issue. (e.g. `unknown-action: 's3:GetObjets' (did you mean 's3:GetObject'?)`)
+func formatLintIssue(issue, value, suggestion string) string {
+	if suggestion == "" {
+		return fmt.Sprintf("%s: '%s'", issue, value)
+	}
+	return fmt.Sprintf("%s: '%s' (did you mean '%s'?)", issue, value, suggestion)
+}
+
+// editDistance returns Levenshtein distance of the strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(first int, others ...int) int {
+	result := first
+	for _, v := range others {
+		if v < result {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package checker
+
+import (
+	"testing"
+)
+
+func TestLintPolicies(t *testing.T) {
+	catalog, err := NewActionCatalog([]byte(`{"services":[
+		{"prefix":"s3","partial":true,"actions":[{"name":"DeleteObject","access_level":"Write"},{"name":"GetObject","access_level":"Read"}]},
+		{"prefix":"sqs","actions":[{"name":"DeleteQueue","access_level":"Write"},{"name":"SendMessage","access_level":"Write"}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		effect         string
+		action         string
+		wantIssue      string
+		wantSeverity   Severity
+		wantSuggestion string
+	}{
+		{"known action", "Deny", "s3:DeleteObject", "", SeverityUnknown, ""},
+		{"known pattern", "Deny", "sqs:Delete*", "", SeverityUnknown, ""},
+		{"unknown action in Allow", "Allow", "sqs:DeleteQueues", LintIssueUnknownAction, SeverityLow, "sqs:DeleteQueue"},
+		{"unknown action of full service", "Deny", "sqs:DeleteQueues", LintIssueUnknownAction, SeverityHigh, "sqs:DeleteQueue"},
+		{"unknown pattern of full service", "Deny", "sqs:Purge*", LintIssueUnknownAction, SeverityHigh, ""},
+		{"typo in partial service", "Deny", "s3:DeleteObjcet", LintIssueUnknownAction, SeverityMedium, "s3:DeleteObject"},
+		{"action not in partial service", "Deny", "s3:PutStorageLensConfiguration", LintIssueUnknownAction, SeverityLow, ""},
+		{"unknown action of partial service in Allow", "Allow", "s3:DeleteObjcet", LintIssueUnknownAction, SeverityLow, "s3:DeleteObject"},
+		{"similar service", "Deny", "sqq:DeleteQueue", LintIssueUnknownService, SeverityMedium, "sqs"},
+		{"unknown service", "Deny", "glue:DeleteTable", LintIssueUnknownService, SeverityLow, ""},
+		{"invalid action", "Deny", "DeleteObject", LintIssueInvalidAction, SeverityHigh, ""},
+		{"invalid action in Allow", "Allow", "DeleteObject", LintIssueInvalidAction, SeverityLow, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPolicy(t, "test", `{"Statement":[{"Effect":"`+tt.effect+`","Action":"`+tt.action+`","Resource":"*"}]}`)
+			findings := lintPolicies(catalog, []*AwsPolicy{p})
+			if tt.wantIssue == "" {
+				if len(findings) != 0 {
+					t.Errorf("lintPolicies() = %+v, want no findings", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("lintPolicies() = %d findings, want 1", len(findings))
+			}
+			f := findings[0]
+			if f.Issue != tt.wantIssue || f.Severity != tt.wantSeverity || f.Suggestion != tt.wantSuggestion {
+				t.Errorf("lintPolicies() = {issue:%s severity:%s suggestion:%s}, want {issue:%s severity:%s suggestion:%s}",
+					f.Issue, f.Severity, f.Suggestion, tt.wantIssue, tt.wantSeverity, tt.wantSuggestion)
+			}
+		})
+	}
+}
+
+func TestBuiltInCatalogIsPartial(t *testing.T) {
+	catalog := DefaultActionCatalog()
+	for _, svc := range catalog.Services {
+		if !catalog.IsPartialService(svc.Prefix) {
+			t.Errorf("%s is not partial", svc.Prefix)
+		}
+	}
+
+	// the service of Service Authorization Reference replaces the partial service.
+	ref, err := NewActionCatalog([]byte(`{"Name":"sqs","Actions":[{"Name":"SendMessage","Annotations":{"Properties":{"IsWrite":true}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := catalog.Merge(ref)
+	if merged.IsPartialService("sqs") {
+		t.Error("sqs is partial after Merge")
+	}
+	if !merged.IsPartialService("s3") {
+		t.Error("s3 is not partial after Merge")
+	}
+}
@@ -0,0 +1,213 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// log levels
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+const logPrefix = "Checker"
+
+var logLevels = []string{LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError}
+
+// Logger is an interface of the leveled logger used in PolicyChecker.
+// Set your own logger into Config.Logger or by PolicyChecker.SetLogger. (e.g. *logrus.Logger and *zap.SugaredLogger implement this)
+type Logger interface {
+	Debugf(template string, params ...interface{})
+	Infof(template string, params ...interface{})
+	Warnf(template string, params ...interface{})
+	Errorf(template string, params ...interface{})
+}
+
+var _ Logger = &StdLogger{}
+
+// StdLogger is the default Logger which writes text or JSON lines into io.Writer.
+//
+//	text: [Checker] [ERROR] Func:[ListEntitiesForPolicy] Error:[AccessDenied], ARN:[arn:aws:iam::aws:policy/ReadOnlyAccess]
+//	json: {"time":"2021-01-01T00:00:00Z","level":"error","message":"Func:[ListEntitiesForPolicy] ...","fields":{"ARN":"arn:aws:iam::aws:policy/ReadOnlyAccess","Error":"AccessDenied","Func":"ListEntitiesForPolicy"}}
+type StdLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	level  int
+	format string
+}
+
+// NewStdLogger creates *StdLogger. Logs lower than the level are not written.
+func NewStdLogger(w io.Writer, level, format string) (*StdLogger, error) {
+	if err := validateLogLevel(level); err != nil {
+		return nil, err
+	}
+	if err := validateLogFormat(format); err != nil {
+		return nil, err
+	}
+
+	if level == "" {
+		level = LogLevelInfo
+	}
+	if format == "" {
+		format = LogFormatText
+	}
+	return &StdLogger{
+		w:      w,
+		level:  indexOfLogLevel(level),
+		format: strings.ToLower(format),
+	}, nil
+}
+
+// Debugf writes debug log.
+func (l *StdLogger) Debugf(template string, params ...interface{}) {
+	l.write(LogLevelDebug, template, params...)
+}
+
+// Infof writes info log.
+func (l *StdLogger) Infof(template string, params ...interface{}) {
+	l.write(LogLevelInfo, template, params...)
+}
+
+// Warnf writes warn log.
+func (l *StdLogger) Warnf(template string, params ...interface{}) {
+	l.write(LogLevelWarn, template, params...)
+}
+
+// Errorf writes error log.
+func (l *StdLogger) Errorf(template string, params ...interface{}) {
+	l.write(LogLevelError, template, params...)
+}
+
+func (l *StdLogger) write(level, template string, params ...interface{}) {
+	if indexOfLogLevel(level) < l.level {
+		return
+	}
+
+	msg := fmt.Sprintf(template, params...)
+	var line string
+	switch l.format {
+	case LogFormatJSON:
+		byt, err := json.Marshal(jsonLog{
+			Time:    time.Now().UTC().Format(time.RFC3339),
+			Level:   level,
+			Message: msg,
+			Fields:  parseLogFields(msg),
+		})
+		if err != nil {
+			return
+		}
+		line = string(byt)
+	default:
+		line = fmt.Sprintf("[%s] [%s] %s", logPrefix, strings.ToUpper(level), msg)
+	}
+
+	// lines from the workers must not be mixed.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintln(l.w, line)
+}
+
+type jsonLog struct {
+	Time    string            `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// parseLogFields parses `Key:[value]` in the message into the fields. (e.g. `Func:[GetGroup] Error:[%s], GroupName:[%s]`)
+// Brackets in the value are kept when they are balanced.
+func parseLogFields(msg string) map[string]string {
+	var fields map[string]string
+	for i := 0; i < len(msg); i++ {
+		if !strings.HasPrefix(msg[i:], ":[") {
+			continue
+		}
+
+		start := i
+		for start > 0 && isLogFieldKeyChar(msg[start-1]) {
+			start--
+		}
+		if start == i {
+			continue
+		}
+
+		depth := 0
+		end := -1
+		for j := i + 1; j < len(msg) && end < 0; j++ {
+			switch msg[j] {
+			case '[':
+				depth++
+			case ']':
+				depth--
+				if depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			break
+		}
+
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[msg[start:i]] = msg[i+2 : end]
+		i = end
+	}
+	return fields
+}
+
+func isLogFieldKeyChar(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9') || b == '_'
+}
+
+// newDefaultLogger creates *StdLogger writing into stderr.
+func newDefaultLogger(level, format string) Logger {
+	l, err := NewStdLogger(os.Stderr, level, format)
+	if err != nil {
+		// invalid level and format are checked in Validate.
+		l, _ = NewStdLogger(os.Stderr, "", "")
+	}
+	return l
+}
+
+func indexOfLogLevel(level string) int {
+	level = strings.ToLower(level)
+	for i, v := range logLevels {
+		if v == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateLogLevel checks if the log level is supported.
+func validateLogLevel(level string) error {
+	if level == "" || indexOfLogLevel(level) >= 0 {
+		return nil
+	}
+	return fmt.Errorf("unsupported log level: '%s'", level)
+}
+
+// validateLogFormat checks if the log format is supported.
+func validateLogFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", LogFormatText, LogFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unsupported log format: '%s'", format)
+}
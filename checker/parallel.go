@@ -0,0 +1,79 @@
+package checker
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	defaultParallel   = 1
+	defaultMaxRetry   = 5
+	retryBaseInterval = 500 * time.Millisecond
+	retryMaxInterval  = 30 * time.Second
+)
+
+// sleep waits before the retry. It's replaced in the tests not to wait.
+var sleep = time.Sleep
+
+// runParallel executes fn for each index of [0, size) by the workers.
+// fn must save the result by the index to keep the order.
+func runParallel(workers, size int, fn func(i int)) {
+	if workers > size {
+		workers = size
+	}
+	if workers <= 1 {
+		for i := 0; i < size; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	ch := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ch {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < size; i++ {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+}
+
+// withRetry executes fn and retries it with exponential backoff when the error is throttling.
+func (c *PolicyChecker) withRetry(funcName string, fn func() error) error {
+	interval := retryBaseInterval
+	maxRetry := c.config.GetMaxRetry()
+	for i := 0; ; i++ {
+		c.loggingDebug("invoking `%s` ...", funcName)
+		err := fn()
+		if err == nil || i >= maxRetry || !isThrottlingError(err) {
+			return err
+		}
+
+		// add jitter to avoid retrying at the same time from the workers.
+		wait := interval/2 + time.Duration(rand.Int63n(int64(interval)))
+		c.loggingWarn("Func:[%s] is throttled, retry after %s ... (%d/%d)", funcName, wait, i+1, maxRetry)
+		sleep(wait)
+
+		interval *= 2
+		if interval > retryMaxInterval {
+			interval = retryMaxInterval
+		}
+	}
+}
+
+// isThrottlingError checks if the error is caused by API rate limit.
+func isThrottlingError(err error) bool {
+	return request.IsErrorThrottle(err)
+}
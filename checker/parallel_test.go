@@ -0,0 +1,158 @@
+package checker
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+func TestRunParallel(t *testing.T) {
+	tests := []struct {
+		workers int
+		size    int
+	}{
+		{0, 10},
+		{1, 10},
+		{4, 0},
+		{4, 1},
+		{4, 100},
+		{16, 5},
+	}
+	for _, tt := range tests {
+		var running, maxRunning int32
+		results := make([]int, tt.size)
+		counts := make([]int32, tt.size)
+		runParallel(tt.workers, tt.size, func(i int) {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				max := atomic.LoadInt32(&maxRunning)
+				if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&counts[i], 1)
+			results[i] = i * i
+			atomic.AddInt32(&running, -1)
+		})
+
+		for i := 0; i < tt.size; i++ {
+			if counts[i] != 1 {
+				t.Errorf("workers:%d size:%d: fn(%d) is called %d times, want 1", tt.workers, tt.size, i, counts[i])
+			}
+			if results[i] != i*i {
+				t.Errorf("workers:%d size:%d: results[%d] = %d, want %d", tt.workers, tt.size, i, results[i], i*i)
+			}
+		}
+		limit := int32(tt.workers)
+		if limit < 1 {
+			limit = 1
+		}
+		if maxRunning > limit {
+			t.Errorf("workers:%d size:%d: %d functions ran concurrently", tt.workers, tt.size, maxRunning)
+		}
+	}
+}
+
+// stubSleep replaces sleep in withRetry and returns the waits.
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var mu sync.Mutex
+	var waits []time.Duration
+	orig := sleep
+	sleep = func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		waits = append(waits, d)
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &waits
+}
+
+func newRetryChecker(t *testing.T, maxRetry int) (*PolicyChecker, *bytes.Buffer) {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	logger, err := NewStdLogger(buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &PolicyChecker{config: Config{MaxRetry: maxRetry}, logger: logger}, buf
+}
+
+func TestWithRetry(t *testing.T) {
+	throttled := awserr.New("Throttling", "Rate exceeded", nil)
+	denied := awserr.New("AccessDenied", "not authorized", nil)
+	eof := errors.New("EOF")
+
+	tests := []struct {
+		name      string
+		maxRetry  int
+		errs      []error // errors returned by each call; nil after the list
+		wantCalls int
+		wantErr   error
+	}{
+		{"success", 3, nil, 1, nil},
+		{"retry on throttling", 3, []error{throttled, throttled}, 3, nil},
+		{"give up after max retry", 3, []error{throttled, throttled, throttled, throttled, throttled}, 4, throttled},
+		{"no retry on other error", 3, []error{denied}, 1, denied},
+		{"no retry on plain error", 3, []error{eof}, 1, eof},
+		{"default max retry", 0, []error{throttled, throttled, throttled, throttled, throttled, throttled, throttled}, defaultMaxRetry + 1, throttled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waits := stubSleep(t)
+			c, _ := newRetryChecker(t, tt.maxRetry)
+
+			calls := 0
+			err := c.withRetry("Test", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if err != tt.wantErr {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(*waits) != calls-1 {
+				t.Errorf("waited %d times, want %d", len(*waits), calls-1)
+			}
+		})
+	}
+}
+
+func TestWithRetryBackoff(t *testing.T) {
+	waits := stubSleep(t)
+	c, buf := newRetryChecker(t, 10)
+
+	throttled := awserr.New("ThrottlingException", "Rate exceeded", nil)
+	_ = c.withRetry("Test", func() error {
+		return throttled
+	})
+
+	if len(*waits) != 10 {
+		t.Fatalf("waited %d times, want 10", len(*waits))
+	}
+	interval := retryBaseInterval
+	for i, w := range *waits {
+		// the wait is the interval with jitter in [interval/2, interval*3/2).
+		if w < interval/2 || w >= interval/2+interval {
+			t.Errorf("wait #%d = %s, want [%s, %s)", i+1, w, interval/2, interval/2+interval)
+		}
+		interval *= 2
+		if interval > retryMaxInterval {
+			interval = retryMaxInterval
+		}
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Func:[Test] is throttled")) {
+		t.Errorf("warning log is not written: %q", buf.String())
+	}
+}
@@ -1,18 +1,27 @@
 package checker
 
 import (
-	"fmt"
+	"errors"
+	"reflect"
 	"sort"
-	"strings"
+	"sync"
 
 	"github.com/evalphobia/aws-sdk-go-wrapper/config"
-	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
 )
 
 // PolicyChecker is struct for checking IAM policies.
 type PolicyChecker struct {
 	config Config
-	client *iam.IAM
+	client IAMClient
+	logger Logger
+
+	checkName string // check name in the config file
+	summary   *CheckSummary
+
+	fetchErrorsMu sync.Mutex
+	fetchErrors   []FetchError
+
+	warnedServices sync.Map // services not in the action catalog which are already warned
 }
 
 // New create *PolicyChecker from empty config.
@@ -26,31 +35,109 @@ func NewWithConfig(conf Config) (*PolicyChecker, error) {
 		return nil, err
 	}
 
-	cli, err := iam.New(config.Config{})
+	cli, err := newIAMClient(conf)
 	if err != nil {
 		return nil, err
 	}
+	return NewWithClient(conf, cli)
+}
 
-	return &PolicyChecker{
+// NewWithClient create *PolicyChecker from config.Config and IAMClient.
+func NewWithClient(conf Config, cli IAMClient) (*PolicyChecker, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	if cli == nil {
+		return nil, errors.New("IAMClient is nil")
+	}
+	warnings, err := conf.ValidateTargets()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &PolicyChecker{
 		config: conf,
 		client: cli,
-	}, nil
+		logger: conf.GetLogger(),
+	}
+	c.config.loadCache()
+	for _, w := range warnings {
+		c.loggingWarn("Func:[ValidateTargets] Warning:[%s]", w)
+	}
+	return c, nil
+}
+
+// newIAMClient creates IAMClient from the snapshot file or AWS API.
+func newIAMClient(conf Config) (IAMClient, error) {
+	if file := conf.GetInputFile(); file != "" {
+		return NewSnapshotClient(file)
+	}
+	return NewAWSClient(config.Config{})
 }
 
-// hasTargetPermission checks if the statements contains target Resource/Action/Service from config.
-func (c *PolicyChecker) hasTargetPermission(statements []iam.Statement) bool {
-	if c.config.ShowAllPolicy {
+// hasTargetPermission checks if the statements grant target Resource/Action/Service from config.
+// The permissions denied by Deny statements in the same policy are not counted.
+func (c *PolicyChecker) hasTargetPermission(statements []Statement) bool {
+	conf := c.config
+	if conf.ShowAllPolicy && !conf.hasConditionRule() && !conf.hasAccessLevelRule() && !conf.hasFilterRule() && !conf.hasExclusionRule() {
 		return true
 	}
 
+	// excluded permissions are handled as denied.
+	denies := append(getDenyStatements(statements), conf.getExclusionStatements()...)
 	for _, s := range statements {
-		if hasTargetPermission(c.config, s) {
+		if !s.IsAllow() || !matchCondition(conf, s) {
+			continue
+		}
+		if conf.hasAccessLevelRule() {
+			c.warnUncataloguedServices(s)
+		}
+		if hasTargetPermission(conf, s, denies) {
 			return true
 		}
 	}
 	return false
 }
 
+// warnUncataloguedServices warns the services of the actions not in the action catalog once per service,
+// because the access levels of their actions are unknown and the statements do not match access level rules.
+func (c *PolicyChecker) warnUncataloguedServices(s Statement) {
+	catalog, _ := c.config.GetActionCatalog()
+	for _, a := range s.Action {
+		svc := catalog.getUncataloguedService(a)
+		if svc == "" {
+			continue
+		}
+		if _, warned := c.warnedServices.LoadOrStore(svc, struct{}{}); !warned {
+			c.loggingWarn("Func:[hasTargetPermission] Warning:[access levels are unknown, the service is not in the action catalog], Service:[%s]", svc)
+		}
+	}
+}
+
+// setExpandedActions sets the actions granted by wildcard actions or NotAction into the policies.
+// When access levels are set in config, only the actions with the access levels are set.
+// The excluded actions are not set.
+func (c *PolicyChecker) setExpandedActions(list []*AwsPolicy) {
+	catalog, _ := c.config.GetActionCatalog()
+	levels := c.config.GetAccessLevels()
+	excludes := c.config.getExclusionStatements()
+	for _, p := range list {
+		p.ExpandedActions = catalog.expandStatements(p.Policy.Statement, excludes, levels)
+	}
+}
+
+// SetLogger sets the logger used instead of Config.Logger or the default logger.
+func (c *PolicyChecker) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+func (c *PolicyChecker) getLogger() Logger {
+	if c.logger == nil {
+		return c.config.GetLogger()
+	}
+	return c.logger
+}
+
 func (c *PolicyChecker) loggingError(template string, params ...interface{}) {
 	if len(params) == 0 {
 		return
@@ -59,43 +146,27 @@ func (c *PolicyChecker) loggingError(template string, params ...interface{}) {
 		return
 	}
 
-	fmt.Printf("[Checker] [ERROR] %s\n", fmt.Sprintf(template, params...))
+	c.getLogger().Errorf(template, params...)
 }
 
-func (c *PolicyChecker) loggingInfo(template string, params ...interface{}) {
-	fmt.Printf("[Checker] [INFO] %s\n", fmt.Sprintf(template, params...))
+func (c *PolicyChecker) loggingWarn(template string, params ...interface{}) {
+	c.getLogger().Warnf(template, params...)
 }
 
-// hasTargetPermission checks if the given statement contains target permissions.
-func hasTargetPermission(c Config, s iam.Statement) bool {
-	if !s.IsAllow() {
-		return false
-	}
-
-	svc := c.GetTargetActionServices()
-	if svc.hasService() {
-		return svc.HasTargetInActions(s.Action)
-	}
-	if containsStringInList(s.Resource, c.GetTargetResources()) {
-		return true
-	}
-	return containsStringInList(s.Action, c.GetTargetActions())
+func (c *PolicyChecker) loggingInfo(template string, params ...interface{}) {
+	c.getLogger().Infof(template, params...)
 }
 
-// containsStringInList checks if targetString contains in the list.
-func containsStringInList(list []string, substrList []string) bool {
-	for _, s := range list {
-		for _, substr := range substrList {
-			if strings.Contains(s, substr) {
-				return true
-			}
-		}
-	}
-	return false
+func (c *PolicyChecker) loggingDebug(template string, params ...interface{}) {
+	c.getLogger().Debugf(template, params...)
 }
 
 // uniqueAndSort removes duplicates and sorts order for string slice.
-func uniqueAndSort(list []string) {
+func uniqueAndSort(list []string) []string {
+	if len(list) == 0 {
+		return list
+	}
+
 	// unique
 	m := make(map[string]struct{}, len(list))
 	for _, v := range list {
@@ -108,6 +179,26 @@ func uniqueAndSort(list []string) {
 
 	// sort
 	sort.Strings(keys)
+	return keys
+}
+
+// newFileHandler creates *FileHandler for the output file.
+func (c *PolicyChecker) newFileHandler() (*FileHandler, error) {
+	return NewFileHandlerWithFormat(c.config.GetOutputFile(), c.config.OutputFormat)
+}
+
+// toObjects converts slice of any type into []interface{} for JSON output.
+func toObjects(list interface{}) []interface{} {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	result := make([]interface{}, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result
 }
 
 // apply fnCols to each AwsPolicy's field and output rows data.
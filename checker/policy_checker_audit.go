@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	policyTypeManaged = "managed"
+	policyTypeInline  = "inline"
+)
+
+// Audit checks managed and inline policies by the risk rules and saves the findings.
+func (c *PolicyChecker) Audit() error {
+	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
+		return err
+	}
+
+	findings, err := c.CollectFindings()
+	if err != nil {
+		return err
+	}
+	findings, suppressed, err := c.suppressFindings(findings)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveFindings(findings); err != nil {
+		return err
+	}
+	severities := make([]Severity, len(findings))
+	for i, v := range findings {
+		severities[i] = v.Severity
+	}
+	return c.finishCheck(CheckTypeAudit, len(findings), severities, suppressed)
+}
+
+// CollectFindings applies the risk rules to the policies which CollectPolicies and CollectInlinePolicies collect.
+func (c *PolicyChecker) CollectFindings() ([]Finding, error) {
+	rules, err := c.config.GetAuditRules()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := c.CollectPolicies()
+	if err != nil {
+		return nil, err
+	}
+	inlinePolicies, err := c.CollectInlinePolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	c.loggingInfo("invoking `checkRules` rules:[%d] policies:[%d] inline_policies:[%d] ...", len(rules), len(policies), len(inlinePolicies))
+	findings := checkRules(rules, policies)
+	return append(findings, checkRules(rules, inlinePolicies)...), nil
+}
+
+// SaveFindings saves the findings to the output file.
+func (c *PolicyChecker) SaveFindings(list []Finding) error {
+	c.loggingInfo("invoking `SaveFindings` size:[%d] ...", len(list))
+
+	f, err := c.newFileHandler()
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"severity",
+		"rule",
+		"policy_type",
+		"policy_arn",
+		"policy_name",
+		"entity",
+		"statement_index",
+		"conditional",
+		"statement",
+		"description",
+	}
+
+	lines := make([][]string, len(list))
+	for i, v := range list {
+		statement, _ := json.MarshalIndent(v.Statement, "", "  ")
+		lines[i] = []string{
+			v.Severity.String(),
+			v.Rule.Name,
+			v.Policy.GetPolicyType(),
+			v.Policy.ARN,
+			v.Policy.PolicyName,
+			strings.Join(v.Policy.GetEntities(), "\n"),
+			strconv.Itoa(v.StatementIndex),
+			strconv.FormatBool(v.Conditional),
+			string(statement),
+			v.Rule.Description,
+		}
+	}
+	return f.WriteAll(headers, lines)
+}
@@ -0,0 +1,77 @@
+package checker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CheckEscalation finds privilege escalation paths of users and roles and saves them.
+func (c *PolicyChecker) CheckEscalation() error {
+	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
+		return err
+	}
+
+	findings, err := c.CollectEscalations()
+	if err != nil {
+		return err
+	}
+	findings, suppressed, err := c.suppressEscalations(findings)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveEscalations(findings); err != nil {
+		return err
+	}
+	return c.finishCheck(CheckTypeEscalation, len(findings), nil, suppressed)
+}
+
+// CollectEscalations finds privilege escalation paths of users and roles.
+func (c *PolicyChecker) CollectEscalations() ([]EscalationFinding, error) {
+	principals, err := c.CollectPrincipals()
+	if err != nil {
+		return nil, err
+	}
+
+	c.loggingInfo("invoking `findEscalationPaths` size:[%d] ...", len(principals))
+	return findEscalationPaths(DefaultEscalationPaths(), principals), nil
+}
+
+// SaveEscalations saves escalation paths to the output file.
+func (c *PolicyChecker) SaveEscalations(list []EscalationFinding) error {
+	c.loggingInfo("invoking `SaveEscalations` size:[%d] ...", len(list))
+
+	f, err := c.newFileHandler()
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"entity_type",
+		"entity_name",
+		"escalation_path",
+		"restricted",
+		"permissions",
+		"description",
+	}
+
+	lines := make([][]string, len(list))
+	for i, v := range list {
+		perms := make([]string, len(v.Permissions))
+		for j, p := range v.Permissions {
+			perms[j] = p.String()
+		}
+		lines[i] = []string{
+			v.Principal.Type,
+			v.Principal.Name,
+			v.Path.Name,
+			strconv.FormatBool(v.Restricted),
+			strings.Join(perms, "\n"),
+			v.Path.Description,
+		}
+	}
+	return f.WriteAll(headers, lines)
+}
@@ -6,59 +6,90 @@ import (
 	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
 )
 
-// CheckInlinePolicies fetches inline policy list.
+// CheckInlinePolicies fetches inline policy list and saves the results.
 func (c *PolicyChecker) CheckInlinePolicies() error {
 	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
 		return err
 	}
 
+	targetList, err := c.CollectInlinePolicies()
+	if err != nil {
+		return err
+	}
+	targetList, suppressed, err := c.suppressPolicies(targetList)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveInlinePolicies(targetList); err != nil {
+		return err
+	}
+	return c.finishCheck(CheckTypeInlinePolicy, len(targetList), nil, suppressed)
+}
+
+// CollectInlinePolicies fetches inline policies which contain target permissions from User/Group/Role.
+func (c *PolicyChecker) CollectInlinePolicies() ([]*AwsPolicy, error) {
 	var targetList []*AwsPolicy
 	users, err := c.fetchUsers()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	targetList = append(targetList, c.fetchInlinePolicyFromUsers(users)...)
 
 	groups, err := c.fetchGroups()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	targetList = append(targetList, c.fetchInlinePolicyFromGroups(groups)...)
+	groupPolicies := c.fetchInlinePolicyFromGroups(groups)
+	// inline policies of the groups are granted to the members.
+	c.fillMembersFromGroup(groupPolicies)
+	targetList = append(targetList, groupPolicies...)
 
 	roles, err := c.fetchRoles()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	targetList = append(targetList, c.fetchInlinePolicyFromRoles(roles)...)
-
-	return c.saveInlinePolicies(targetList)
+	c.setExpandedActions(targetList)
+	return targetList, nil
 }
 
 // fetchUsers executes iam:ListUsers.
 func (c *PolicyChecker) fetchUsers() ([]iam.User, error) {
 	c.loggingInfo("invoking `fetchUsers` ...")
 
-	list, err := c.client.ListUsers()
+	var list []iam.User
+	err := c.withRetry("ListUsers", func() (err error) {
+		list, err = c.client.ListUsers()
+		return err
+	})
 	c.loggingError("Func:[ListUsers] Error:[%s]", err)
-	return list, err
+	return c.filterUsers(list), err
 }
 
 // fetchGroups executes iam:ListGroups.
 func (c *PolicyChecker) fetchGroups() ([]iam.Group, error) {
 	c.loggingInfo("invoking `fetchGroups` ...")
 
-	list, err := c.client.ListGroups()
+	var list []iam.Group
+	err := c.withRetry("ListGroups", func() (err error) {
+		list, err = c.client.ListGroups()
+		return err
+	})
 	c.loggingError("Func:[ListGroups] Error:[%s]", err)
-	return list, err
+	return c.filterGroups(list), err
 }
 
 // fetchRoles executes iam:ListRoles.
 func (c *PolicyChecker) fetchRoles() ([]iam.Role, error) {
 	c.loggingInfo("invoking `fetchRoles` ...")
 
-	list, err := c.client.ListRoles()
+	var list []iam.Role
+	err := c.withRetry("ListRoles", func() (err error) {
+		list, err = c.client.ListRoles()
+		return err
+	})
 	c.loggingError("Func:[ListRoles] Error:[%s]", err)
-	return list, err
+	return c.filterRoles(list), err
 }
 
 // fetchInlinePolicyFromUsers fetches inline policies from the users.
@@ -66,25 +97,38 @@ func (c *PolicyChecker) fetchInlinePolicyFromUsers(users []iam.User) []*AwsPolic
 	c.loggingInfo("invoking `fetchInlinePolicyFromUsers` size:[%d] ...", len(users))
 
 	cli := c.client
-	targetList := make([]*AwsPolicy, 0, len(users))
-	for _, u := range users {
-		policies, err := cli.ListUserPolicies(u.UserName)
+	results := make([][]*AwsPolicy, len(users))
+	runParallel(c.config.GetParallel(), len(users), func(i int) {
+		u := users[i]
+		var policies []string
+		err := c.withRetry("ListUserPolicies", func() (err error) {
+			policies, err = cli.ListUserPolicies(u.UserName)
+			return err
+		})
 		switch {
 		case err != nil:
-			c.loggingError("Func:[ListUserPolicies] Error:[%s], UserName:[%s]", err, u.UserName)
-			continue
+			c.addFetchError(FetchError{Func: "ListUserPolicies", Error: err.Error(), Entity: entityUser + "/" + u.UserName})
+			return
 		case len(policies) == 0:
-			continue
+			return
 		}
 
 		for _, policyName := range policies {
-			policy, err := cli.GetUserPolicyDocument(u.UserName, policyName)
+			if c.config.isExcludedPolicy(policyName, "") {
+				continue
+			}
+
+			var policy *PolicyDocument
+			err := c.withRetry("GetUserPolicyDocument", func() (err error) {
+				policy, err = cli.GetUserPolicyDocument(u.UserName, policyName)
+				return err
+			})
 			switch {
 			case err != nil:
-				c.loggingError("Func:[GetUserPolicyDocument] Error:[%s], UserName:[%s], PolicyName:[%s]", err, u.UserName, policyName)
+				c.addFetchError(FetchError{Func: "GetUserPolicyDocument", Error: err.Error(), Entity: entityUser + "/" + u.UserName, PolicyName: policyName})
 				continue
 			case policy == nil:
-				c.loggingError("Func:[GetUserPolicyDocument] Error:[EmptyPolicyDocument], UserName:[%s], PolicyName:[%s]", u.UserName, policyName)
+				c.addFetchError(FetchError{Func: "GetUserPolicyDocument", Error: "EmptyPolicyDocument", Entity: entityUser + "/" + u.UserName, PolicyName: policyName})
 				continue
 			}
 
@@ -94,14 +138,19 @@ func (c *PolicyChecker) fetchInlinePolicyFromUsers(users []iam.User) []*AwsPolic
 			}
 
 			ap := AwsPolicy{
-				AttachedUsers: []string{u.UserName},
-				PolicyName:    policyName,
+				AttachedUsers:    []string{u.UserName},
+				AttachedAllUsers: []string{u.UserName},
+				PolicyName:       policyName,
 			}
 			ap.SetPolicy(*policy)
-			targetList = append(targetList, &ap)
+			results[i] = append(results[i], &ap)
 		}
-	}
+	})
 
+	targetList := make([]*AwsPolicy, 0, len(users))
+	for _, list := range results {
+		targetList = append(targetList, list...)
+	}
 	return targetList
 }
 
@@ -110,25 +159,38 @@ func (c *PolicyChecker) fetchInlinePolicyFromGroups(groups []iam.Group) []*AwsPo
 	c.loggingInfo("invoking `fetchInlinePolicyFromGroups` size:[%d] ...", len(groups))
 
 	cli := c.client
-	targetList := make([]*AwsPolicy, 0, len(groups))
-	for _, g := range groups {
-		policies, err := cli.ListGroupPolicies(g.GroupName)
+	results := make([][]*AwsPolicy, len(groups))
+	runParallel(c.config.GetParallel(), len(groups), func(i int) {
+		g := groups[i]
+		var policies []string
+		err := c.withRetry("ListGroupPolicies", func() (err error) {
+			policies, err = cli.ListGroupPolicies(g.GroupName)
+			return err
+		})
 		switch {
 		case err != nil:
-			c.loggingError("Func:[ListGroupPolicies] Error:[%s], GroupName:[%s]", err, g.GroupName)
-			continue
+			c.addFetchError(FetchError{Func: "ListGroupPolicies", Error: err.Error(), Entity: entityGroup + "/" + g.GroupName})
+			return
 		case len(policies) == 0:
-			continue
+			return
 		}
 
 		for _, policyName := range policies {
-			policy, err := cli.GetGroupPolicyDocument(g.GroupName, policyName)
+			if c.config.isExcludedPolicy(policyName, "") {
+				continue
+			}
+
+			var policy *PolicyDocument
+			err := c.withRetry("GetGroupPolicyDocument", func() (err error) {
+				policy, err = cli.GetGroupPolicyDocument(g.GroupName, policyName)
+				return err
+			})
 			switch {
 			case err != nil:
-				c.loggingError("Func:[GetGroupPolicyDocument] Error:[%s], GroupName:[%s], PolicyName:[%s]", err, g.GroupName, policyName)
+				c.addFetchError(FetchError{Func: "GetGroupPolicyDocument", Error: err.Error(), Entity: entityGroup + "/" + g.GroupName, PolicyName: policyName})
 				continue
 			case policy == nil:
-				c.loggingError("Func:[GetGroupPolicyDocument] Error:[EmptyPolicyDocument], GroupName:[%s], PolicyName:[%s]", g.GroupName, policyName)
+				c.addFetchError(FetchError{Func: "GetGroupPolicyDocument", Error: "EmptyPolicyDocument", Entity: entityGroup + "/" + g.GroupName, PolicyName: policyName})
 				continue
 			}
 
@@ -144,10 +206,14 @@ func (c *PolicyChecker) fetchInlinePolicyFromGroups(groups []iam.Group) []*AwsPo
 				PolicyName: policyName,
 			}
 			ap.SetPolicy(*policy)
-			targetList = append(targetList, &ap)
+			results[i] = append(results[i], &ap)
 		}
-	}
+	})
 
+	targetList := make([]*AwsPolicy, 0, len(groups))
+	for _, list := range results {
+		targetList = append(targetList, list...)
+	}
 	return targetList
 }
 
@@ -156,25 +222,39 @@ func (c *PolicyChecker) fetchInlinePolicyFromRoles(roles []iam.Role) []*AwsPolic
 	c.loggingInfo("invoking `fetchInlinePolicyFromRoles` size:[%d] ...", len(roles))
 
 	cli := c.client
-	targetList := make([]*AwsPolicy, 0, len(roles))
-	for _, r := range roles {
-		policies, err := cli.ListRolePolicies(r.RoleName)
+	results := make([][]*AwsPolicy, len(roles))
+	runParallel(c.config.GetParallel(), len(roles), func(i int) {
+		r := roles[i]
+		var policies []string
+		err := c.withRetry("ListRolePolicies", func() (err error) {
+			policies, err = cli.ListRolePolicies(r.RoleName)
+			return err
+		})
 		switch {
 		case err != nil:
-			c.loggingError("Func:[ListRolePolicies] Error:[%s], RoleName:[%s]", err, r.RoleName)
-			continue
+			c.addFetchError(FetchError{Func: "ListRolePolicies", Error: err.Error(), Entity: entityRole + "/" + r.RoleName})
+			return
 		case len(policies) == 0:
-			continue
+			return
 		}
 
+		trust := c.newRoleTrust(r)
 		for _, policyName := range policies {
-			policy, err := cli.GetRolePolicyDocument(r.RoleName, policyName)
+			if c.config.isExcludedPolicy(policyName, "") {
+				continue
+			}
+
+			var policy *PolicyDocument
+			err := c.withRetry("GetRolePolicyDocument", func() (err error) {
+				policy, err = cli.GetRolePolicyDocument(r.RoleName, policyName)
+				return err
+			})
 			switch {
 			case err != nil:
-				c.loggingError("Func:[GetRolePolicyDocument] Error:[%s], RoleName:[%s], PolicyName:[%s]", err, r.RoleName, policyName)
+				c.addFetchError(FetchError{Func: "GetRolePolicyDocument", Error: err.Error(), Entity: entityRole + "/" + r.RoleName, PolicyName: policyName})
 				continue
 			case policy == nil:
-				c.loggingError("Func:[GetRolePolicyDocument] Error:[EmptyPolicyDocument], GroupName:[%s], PolicyName:[%s]", r.RoleName, policyName)
+				c.addFetchError(FetchError{Func: "GetRolePolicyDocument", Error: "EmptyPolicyDocument", Entity: entityRole + "/" + r.RoleName, PolicyName: policyName})
 				continue
 			}
 
@@ -186,23 +266,31 @@ func (c *PolicyChecker) fetchInlinePolicyFromRoles(roles []iam.Role) []*AwsPolic
 			ap := AwsPolicy{
 				AttachedRoles: []string{r.RoleName},
 				PolicyName:    policyName,
+				Trust:         trust,
 			}
 			ap.SetPolicy(*policy)
-			targetList = append(targetList, &ap)
+			results[i] = append(results[i], &ap)
 		}
-	}
+	})
 
+	targetList := make([]*AwsPolicy, 0, len(roles))
+	for _, list := range results {
+		targetList = append(targetList, list...)
+	}
 	return targetList
 }
 
-// saveInlinePolicies saves inline policy list results to local file.
-func (c *PolicyChecker) saveInlinePolicies(list []*AwsPolicy) error {
-	c.loggingInfo("invoking `saveInlinePolicies` size:[%d] ...", len(list))
+// SaveInlinePolicies saves inline policy list results to the output file.
+func (c *PolicyChecker) SaveInlinePolicies(list []*AwsPolicy) error {
+	c.loggingInfo("invoking `SaveInlinePolicies` size:[%d] ...", len(list))
 
-	f, err := NewFileHandler(c.config.GetOutputFile())
+	f, err := c.newFileHandler()
 	if err != nil {
 		return err
 	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
 
 	// CSV headers
 	headers := []string{
@@ -210,18 +298,33 @@ func (c *PolicyChecker) saveInlinePolicies(list []*AwsPolicy) error {
 		"entity_name",
 		"policy_name",
 		"policy_action",
+		"expanded_action",
 		"policy_resource_action",
+		"attached_group_user",
+		"attached_all_user",
+		"role_trusted_principal",
+		"role_trust_issue",
 	}
 
 	// CSV row
 	fnCols := func(p *AwsPolicy) []string {
 		typ, entities := p.GetEntityAndType()
+		var principals, issues []string
+		if p.Trust != nil {
+			principals = p.Trust.GetPrincipals()
+			issues = p.Trust.GetIssues()
+		}
 		return []string{
 			typ,
 			strings.Join(entities, "\n"),
 			p.PolicyName,
 			strings.Join(p.PolicyActions, "\n"),
+			strings.Join(p.ExpandedActions, "\n"),
 			strings.Join(GetResourceAndAction(p.PolicyResourceActions), "\n"),
+			strings.Join(p.AttachedGroupUsers, "\n"),
+			strings.Join(p.AttachedAllUsers, "\n"),
+			strings.Join(principals, "\n"),
+			strings.Join(issues, "\n"),
 		}
 	}
 
@@ -0,0 +1,95 @@
+package checker
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Lint checks actions in managed and inline policies by the action catalog and saves the findings.
+func (c *PolicyChecker) Lint() error {
+	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
+		return err
+	}
+
+	findings, err := c.CollectLintFindings()
+	if err != nil {
+		return err
+	}
+	findings, suppressed, err := c.suppressLintFindings(findings)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveLintFindings(findings); err != nil {
+		return err
+	}
+	severities := make([]Severity, len(findings))
+	for i, v := range findings {
+		severities[i] = v.Severity
+	}
+	return c.finishCheck(CheckTypeLint, len(findings), severities, suppressed)
+}
+
+// CollectLintFindings checks actions in the policies which CollectPolicies and CollectInlinePolicies collect.
+func (c *PolicyChecker) CollectLintFindings() ([]LintFinding, error) {
+	catalog, err := c.config.GetActionCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	policies, err := c.CollectPolicies()
+	if err != nil {
+		return nil, err
+	}
+	inlinePolicies, err := c.CollectInlinePolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	c.loggingInfo("invoking `lintPolicies` catalog:[%d] policies:[%d] inline_policies:[%d] ...", catalog.Size(), len(policies), len(inlinePolicies))
+	findings := lintPolicies(catalog, policies)
+	return append(findings, lintPolicies(catalog, inlinePolicies)...), nil
+}
+
+// SaveLintFindings saves the findings to the output file.
+func (c *PolicyChecker) SaveLintFindings(list []LintFinding) error {
+	c.loggingInfo("invoking `SaveLintFindings` size:[%d] ...", len(list))
+
+	f, err := c.newFileHandler()
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"severity",
+		"issue",
+		"policy_type",
+		"policy_arn",
+		"policy_name",
+		"entity",
+		"statement_index",
+		"effect",
+		"action",
+		"suggestion",
+	}
+
+	lines := make([][]string, len(list))
+	for i, v := range list {
+		lines[i] = []string{
+			v.Severity.String(),
+			v.Issue,
+			v.Policy.GetPolicyType(),
+			v.Policy.ARN,
+			v.Policy.PolicyName,
+			strings.Join(v.Policy.GetEntities(), "\n"),
+			strconv.Itoa(v.StatementIndex),
+			v.Effect,
+			v.Action,
+			v.Suggestion,
+		}
+	}
+	return f.WriteAll(headers, lines)
+}
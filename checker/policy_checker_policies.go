@@ -1,52 +1,118 @@
 package checker
 
 import (
+	"strconv"
 	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
+	SDK "github.com/aws/aws-sdk-go/service/iam"
 	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
 )
 
-// CheckPolicies fetches policy list and check the permissions.
+// CheckPolicies fetches policy list, checks the permissions and saves the results.
 func (c *PolicyChecker) CheckPolicies() error {
 	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
 		return err
 	}
 
-	list, err := c.fetchAwsPolicies()
+	targetList, err := c.CollectPolicies()
+	if err != nil {
+		return err
+	}
+	targetList, suppressed, err := c.suppressPolicies(targetList)
 	if err != nil {
 		return err
 	}
+	if err := c.SavePolicies(targetList); err != nil {
+		return err
+	}
+	return c.finishCheck(CheckTypePolicy, len(targetList), nil, suppressed)
+}
+
+// CollectPolicies fetches policies which contain target permissions with the attached entities.
+func (c *PolicyChecker) CollectPolicies() ([]*AwsPolicy, error) {
+	list, err := c.fetchAwsPolicies()
+	if err != nil {
+		return nil, err
+	}
 
 	targetList := c.fetchTargetPolicyWithBody(list)
-	c.fetchAndSetEntity(targetList)
+	targetList = c.fetchAndSetEntity(targetList)
 	c.fillMembersFromGroup(targetList)
-	return c.savePolicies(targetList)
+	c.setRoleTrusts(targetList)
+	c.setExpandedActions(targetList)
+	return targetList, nil
 }
 
-// fetchAwsPolicies executes iam:ListAttachedPolicies.
+// fetchAwsPolicies executes iam:ListPolicies.
 func (c *PolicyChecker) fetchAwsPolicies() ([]iam.Policy, error) {
-	c.loggingInfo("invoking `fetchAwsPolicies` ...")
+	scope := c.config.GetPolicyScope()
+	onlyAttached := !c.config.IncludeUnattached
+	c.loggingInfo("invoking `fetchAwsPolicies` scope:[%s] only_attached:[%t] ...", scope, onlyAttached)
 
-	list, err := c.client.ListAttachedPolicies()
-	c.loggingError("Func:[ListAttachedPolicies] Error:[%s]", err)
-	return list, err
+	var list []iam.Policy
+	err := c.withRetry("ListPolicies", func() (err error) {
+		list, err = c.client.ListPolicies(scope, onlyAttached)
+		return err
+	})
+	c.loggingError("Func:[ListPolicies] Error:[%s]", err)
+	return c.filterPolicies(list), err
 }
 
 // fetchTargetPolicyWithBody fetches policy body and create a list of the policies which contains target permissions.
+// When AllVersions is set, non-default versions broader than the default version are added.
 func (c *PolicyChecker) fetchTargetPolicyWithBody(list []iam.Policy) []*AwsPolicy {
 	c.loggingInfo("invoking `fetchTargetPolicyWithBody` size:[%d] ...", len(list))
 
-	cli := c.client
+	results := make([][]*AwsPolicy, len(list))
+	runParallel(c.config.GetParallel(), len(list), func(i int) {
+		p := list[i]
+		policy, ok := c.fetchPolicyVersion(p.ARN, p.VersionID)
+		if !ok {
+			return
+		}
+
+		// filter policies by Resource/Action/Service from config.
+		if c.hasTargetPermission(policy.Statement) {
+			results[i] = append(results[i], newManagedPolicy(p, p.VersionID, policy))
+		}
+		if c.config.AllVersions {
+			results[i] = append(results[i], c.fetchBroaderVersions(p, policy)...)
+		}
+	})
+
 	targetList := make([]*AwsPolicy, 0, len(list))
-	for _, p := range list {
-		v, err := cli.GetPolicyVersion(p.ARN, p.VersionID)
-		if err != nil {
-			c.loggingError("Func:[GetPolicyVersion] Error:[%s], ARN:[%s]", err, p.ARN)
+	for _, list := range results {
+		targetList = append(targetList, list...)
+	}
+	return targetList
+}
+
+// fetchBroaderVersions fetches non-default versions of the policy and returns versions broader than the default version.
+func (c *PolicyChecker) fetchBroaderVersions(p iam.Policy, defaultPolicy PolicyDocument) []*AwsPolicy {
+	var versions []*SDK.PolicyVersion
+	err := c.withRetry("ListPolicyVersions", func() (err error) {
+		versions, err = c.client.ListPolicyVersions(p.ARN)
+		return err
+	})
+	if err != nil {
+		c.addFetchError(FetchError{Func: "ListPolicyVersions", Error: err.Error(), PolicyARN: p.ARN})
+		return nil
+	}
+
+	var result []*AwsPolicy
+	for _, v := range versions {
+		versionID := aws.StringValue(v.VersionId)
+		if versionID == p.VersionID {
 			continue
 		}
-		policy, err := iam.NewPolicyDocumentFromDocument(*v.Document)
-		if err != nil {
-			c.loggingError("Func:[NewPolicyFromDocument] Error:[%s], ARN:[%s]", err, p.ARN)
+
+		policy, ok := c.fetchPolicyVersion(p.ARN, versionID)
+		if !ok {
+			continue
+		}
+		broader := getBroaderPermissions(policy, defaultPolicy)
+		if len(broader) == 0 {
 			continue
 		}
 
@@ -55,56 +121,138 @@ func (c *PolicyChecker) fetchTargetPolicyWithBody(list []iam.Policy) []*AwsPolic
 			continue
 		}
 
-		ap := AwsPolicy{
-			ARN:        p.ARN,
-			PolicyName: p.PolicyName,
-		}
-		ap.SetPolicy(policy)
-		targetList = append(targetList, &ap)
+		ap := newManagedPolicy(p, versionID, policy)
+		ap.NonDefaultVersion = true
+		ap.BroaderPermissions = broader
+		result = append(result, ap)
 	}
+	return result
+}
 
-	return targetList
+// fetchPolicyVersion executes iam:GetPolicyVersion and returns the policy document.
+func (c *PolicyChecker) fetchPolicyVersion(arn, versionID string) (PolicyDocument, bool) {
+	var v *SDK.PolicyVersion
+	err := c.withRetry("GetPolicyVersion", func() (err error) {
+		v, err = c.client.GetPolicyVersion(arn, versionID)
+		return err
+	})
+	if err != nil {
+		c.addFetchError(FetchError{Func: "GetPolicyVersion", Error: err.Error(), PolicyARN: arn, VersionID: versionID})
+		return PolicyDocument{}, false
+	}
+	policy, err := NewPolicyDocumentFromDocument(aws.StringValue(v.Document))
+	if err != nil {
+		c.addFetchError(FetchError{Func: "NewPolicyFromDocument", Error: err.Error(), PolicyARN: arn, VersionID: versionID})
+		return PolicyDocument{}, false
+	}
+	return policy, true
+}
+
+// newManagedPolicy creates *AwsPolicy from the managed policy and the document of the version.
+func newManagedPolicy(p iam.Policy, versionID string, policy PolicyDocument) *AwsPolicy {
+	ap := &AwsPolicy{
+		ARN:             p.ARN,
+		PolicyName:      p.PolicyName,
+		VersionID:       versionID,
+		AttachmentCount: p.AttachmentCount,
+	}
+	ap.SetPolicy(policy)
+	return ap
 }
 
 // fetchAndSetEntity fetches PolicyEntity and sets them into *AwsPolicy.
-func (c *PolicyChecker) fetchAndSetEntity(list []*AwsPolicy) {
+// Versions of the same policy share the entities.
+// The policies attached to only the excluded entities are removed from the list.
+func (c *PolicyChecker) fetchAndSetEntity(list []*AwsPolicy) []*AwsPolicy {
 	c.loggingInfo("invoking `fetchAndSetEntity` size:[%d] ...", len(list))
 
-	cli := c.client
+	policies := make(map[string][]*AwsPolicy)
+	var arns []string
 	for _, p := range list {
-		entList, err := cli.ListEntitiesForPolicy(p.ARN)
+		if _, ok := policies[p.ARN]; !ok {
+			arns = append(arns, p.ARN)
+		}
+		policies[p.ARN] = append(policies[p.ARN], p)
+	}
+
+	cli := c.client
+	excluded := make([]bool, len(arns))
+	runParallel(c.config.GetParallel(), len(arns), func(i int) {
+		arn := arns[i]
+		// unattached policies do not have any entity.
+		if c.config.IncludeUnattached && policies[arn][0].AttachmentCount == 0 {
+			return
+		}
+
+		var entList []iam.PolicyEntity
+		err := c.withRetry("ListEntitiesForPolicy", func() (err error) {
+			entList, err = cli.ListEntitiesForPolicy(arn)
+			return err
+		})
 		if err != nil {
-			c.loggingError("Func:[NewPolicyFromDocument] Error:[%s], ARN:[%s]", err, p.ARN)
-			continue
+			c.addFetchError(FetchError{Func: "ListEntitiesForPolicy", Error: err.Error(), PolicyARN: arn})
+			return
+		}
+
+		filtered := c.filterEntities(entList)
+		if len(entList) != 0 && len(filtered) == 0 {
+			excluded[i] = true
+			return
+		}
+		for _, p := range policies[arn] {
+			p.SetEntityList(filtered)
+		}
+	})
+
+	result := make([]*AwsPolicy, 0, len(list))
+	for i, arn := range arns {
+		if !excluded[i] {
+			result = append(result, policies[arn]...)
 		}
-		p.SetEntityList(entList)
 	}
+	return result
 }
 
 // fillMembersFromGroup fetches users of the group and sets them into *AwsPolicy
 func (c *PolicyChecker) fillMembersFromGroup(list []*AwsPolicy) {
-	c.loggingInfo("invoking `fetchAndSetEntity` size:[%d] ...", len(list))
+	c.loggingInfo("invoking `fillMembersFromGroup` size:[%d] ...", len(list))
 
 	cli := c.client
 	groupMembers := make(map[string][]string)
+	var groupNames []string
 	for _, p := range list {
 		for _, g := range p.AttachedGroups {
+			if _, ok := groupMembers[g.Name]; ok {
+				continue
+			}
 			groupMembers[g.Name] = nil
+			groupNames = append(groupNames, g.Name)
 		}
 	}
 
-	for key := range groupMembers {
-		o, err := cli.GetGroup(key)
+	members := make([][]string, len(groupNames))
+	runParallel(c.config.GetParallel(), len(groupNames), func(i int) {
+		key := groupNames[i]
+		var o *SDK.GetGroupOutput
+		err := c.withRetry("GetGroup", func() (err error) {
+			o, err = cli.GetGroup(key)
+			return err
+		})
 		if err != nil {
-			c.loggingError("Func:[GetGroup] Error:[%s], Group:[%s]", err, key)
-			continue
+			c.addFetchError(FetchError{Func: "GetGroup", Error: err.Error(), Entity: entityGroup + "/" + key})
+			return
 		}
 
-		users := make([]string, len(o.Users))
-		for i, u := range o.Users {
-			users[i] = *u.UserName
+		users := make([]string, 0, len(o.Users))
+		for _, u := range o.Users {
+			if !c.config.isExcludedEntity(entityUser, *u.UserName) {
+				users = append(users, *u.UserName)
+			}
 		}
-		groupMembers[key] = users
+		members[i] = users
+	})
+	for i, key := range groupNames {
+		groupMembers[key] = members[i]
 	}
 
 	for _, p := range list {
@@ -117,47 +265,120 @@ func (c *PolicyChecker) fillMembersFromGroup(list []*AwsPolicy) {
 			p.AttachedGroups[i] = g
 			p.AttachedGroupUsers = append(p.AttachedGroupUsers, u...)
 		}
-		uniqueAndSort(p.AttachedGroupUsers)
+		p.AttachedGroupUsers = uniqueAndSort(p.AttachedGroupUsers)
 
 		p.AttachedAllUsers = append(p.AttachedAllUsers, p.AttachedGroupUsers...)
-		uniqueAndSort(p.AttachedAllUsers)
+		p.AttachedAllUsers = uniqueAndSort(p.AttachedAllUsers)
+	}
+}
+
+// setRoleTrusts fetches roles and sets the trust policies of the attached roles into *AwsPolicy.
+func (c *PolicyChecker) setRoleTrusts(list []*AwsPolicy) {
+	hasRole := false
+	for _, p := range list {
+		if len(p.AttachedRoles) != 0 {
+			hasRole = true
+			break
+		}
 	}
+	if !hasRole {
+		return
+	}
+
+	roles, err := c.fetchRoles()
+	if err != nil {
+		c.addFetchError(FetchError{Func: "ListRoles", Error: err.Error()})
+		return
+	}
+	roleMap := make(map[string]iam.Role, len(roles))
+	for _, r := range roles {
+		roleMap[r.RoleName] = r
+	}
+
+	c.loggingInfo("invoking `setRoleTrusts` size:[%d] ...", len(list))
+	trusts := make(map[string]*RoleTrust)
+	for _, p := range list {
+		for _, name := range p.AttachedRoles {
+			t, ok := trusts[name]
+			if !ok {
+				if r, exists := roleMap[name]; exists {
+					t = c.newRoleTrust(r)
+				}
+				trusts[name] = t
+			}
+			if t != nil {
+				p.AttachedRoleTrusts = append(p.AttachedRoleTrusts, t)
+			}
+		}
+	}
+}
+
+// getRoleTrustLines returns the principals and the issues of the trust policies with the role names.
+// (e.g. `app: AWS:arn:aws:iam::123456789012:root`, `app: high: unknown-account (AWS:123456789012)`)
+func getRoleTrustLines(list []*RoleTrust) (principals, issues []string) {
+	for _, t := range list {
+		for _, v := range t.GetPrincipals() {
+			principals = append(principals, t.RoleName+": "+v)
+		}
+		for _, v := range t.GetIssues() {
+			issues = append(issues, t.RoleName+": "+v)
+		}
+	}
+	return principals, issues
 }
 
-// savePolicies saves policy list results to local file.
-func (c *PolicyChecker) savePolicies(list []*AwsPolicy) error {
-	c.loggingInfo("invoking `savePolicies` size:[%d] ...", len(list))
+// SavePolicies saves policy list results to the output file.
+func (c *PolicyChecker) SavePolicies(list []*AwsPolicy) error {
+	c.loggingInfo("invoking `SavePolicies` size:[%d] ...", len(list))
 
-	f, err := NewFileHandler(c.config.GetOutputFile())
+	f, err := c.newFileHandler()
 	if err != nil {
 		return err
 	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
 
 	// CSV headers
 	headers := []string{
 		"policy_arn",
 		"policy_name",
+		"policy_type",
+		"attachment_count",
+		"version_id",
 		"policy_action",
+		"expanded_action",
 		"policy_resource_action",
+		"broader_permission",
 		"attached_user",
 		"attached_group",
 		"attached_group_user",
 		"attached_all_user",
 		"attached_role",
+		"role_trusted_principal",
+		"role_trust_issue",
 	}
 
 	// CSV row
 	fnCols := func(p *AwsPolicy) []string {
+		principals, issues := getRoleTrustLines(p.AttachedRoleTrusts)
 		return []string{
 			p.ARN,
 			p.PolicyName,
+			p.GetScope(),
+			strconv.FormatInt(p.AttachmentCount, 10),
+			p.VersionID,
 			strings.Join(p.PolicyActions, "\n"),
+			strings.Join(p.ExpandedActions, "\n"),
 			strings.Join(GetResourceAndAction(p.PolicyResourceActions), "\n"),
+			strings.Join(p.BroaderPermissions, "\n"),
 			strings.Join(p.AttachedUsers, "\n"),
 			strings.Join(GetGroupNames(p.AttachedGroups), "\n"),
 			strings.Join(p.AttachedGroupUsers, "\n"),
 			strings.Join(p.AttachedAllUsers, "\n"),
 			strings.Join(p.AttachedRoles, "\n"),
+			strings.Join(principals, "\n"),
+			strings.Join(issues, "\n"),
 		}
 	}
 
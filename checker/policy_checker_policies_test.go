@@ -0,0 +1,176 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSnapshotFile = "testdata/authorization_details.json"
+
+// newTestChecker returns *PolicyChecker of the snapshot file in testdata and the log output.
+func newTestChecker(t *testing.T, conf Config) (*PolicyChecker, *bytes.Buffer) {
+	t.Helper()
+	cli, err := NewSnapshotClient(testSnapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	logger, err := NewStdLogger(buf, "warn", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.OutputFile == "" {
+		conf.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+	}
+	conf.Logger = logger
+	c, err := NewWithClient(conf, cli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, buf
+}
+
+// readCSV reads the CSV file and returns the rows as maps of the headers.
+func readCSV(t *testing.T, file string) []map[string]string {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	lines, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) == 0 {
+		t.Fatalf("%s is empty", file)
+	}
+	result := make([]map[string]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		row := make(map[string]string, len(line))
+		for i, v := range line {
+			row[lines[0][i]] = v
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// findPolicy returns the policy of the name in the list.
+func findPolicy(list []*AwsPolicy, name string) *AwsPolicy {
+	for _, p := range list {
+		if p.PolicyName == name {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestCollectPoliciesRoleTrusts(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy         string
+		wantRoles      []string
+		wantPrincipals string
+		wantIssues     string
+	}{
+		{
+			policy:         "DeveloperAccess",
+			wantRoles:      []string{"app"},
+			wantPrincipals: "app: Service:ec2.amazonaws.com",
+			wantIssues:     "",
+		},
+		{
+			policy:         "AuditAccess",
+			wantRoles:      []string{"partner-audit"},
+			wantPrincipals: "partner-audit: AWS:arn:aws:iam::210987654321:root",
+			wantIssues:     "partner-audit: high: unknown-account (AWS:arn:aws:iam::210987654321:root)\npartner-audit: medium: cross-account-no-external-id (AWS:arn:aws:iam::210987654321:root)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			p := findPolicy(list, tt.policy)
+			if p == nil {
+				t.Fatalf("%s is not in the results", tt.policy)
+			}
+			if len(p.AttachedRoleTrusts) != len(tt.wantRoles) {
+				t.Fatalf("AttachedRoleTrusts = %d roles, want %d", len(p.AttachedRoleTrusts), len(tt.wantRoles))
+			}
+			for i, name := range tt.wantRoles {
+				if got := p.AttachedRoleTrusts[i].RoleName; got != name {
+					t.Errorf("AttachedRoleTrusts[%d] = %s, want %s", i, got, name)
+				}
+			}
+			principals, issues := getRoleTrustLines(p.AttachedRoleTrusts)
+			if got := strings.Join(principals, "\n"); got != tt.wantPrincipals {
+				t.Errorf("principals = %q, want %q", got, tt.wantPrincipals)
+			}
+			if got := strings.Join(issues, "\n"); got != tt.wantIssues {
+				t.Errorf("issues = %q, want %q", got, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestSavePoliciesRoleTrusts(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SavePolicies(list); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readCSV(t, c.config.GetOutputFile())
+	found := false
+	for _, row := range rows {
+		switch row["policy_name"] {
+		case "AuditAccess":
+			found = true
+			if got := row["role_trusted_principal"]; got != "partner-audit: AWS:arn:aws:iam::210987654321:root" {
+				t.Errorf("role_trusted_principal = %q", got)
+			}
+			if got := row["role_trust_issue"]; !strings.Contains(got, "partner-audit: high: unknown-account") {
+				t.Errorf("role_trust_issue = %q", got)
+			}
+		case "AWSSupportServiceRolePolicy":
+			if got := row["role_trusted_principal"]; got != "AWSServiceRoleForSupport: Service:support.amazonaws.com" {
+				t.Errorf("role_trusted_principal = %q", got)
+			}
+		}
+	}
+	if !found {
+		t.Error("AuditAccess is not in the output")
+	}
+}
+
+func TestCollectPoliciesGroupUsers(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// alice is in both of the groups attaching DeveloperAccess.
+	p := findPolicy(list, "DeveloperAccess")
+	if p == nil {
+		t.Fatal("DeveloperAccess is not in the results")
+	}
+	if got := strings.Join(p.AttachedGroupUsers, " "); got != "alice bob ci-bot" {
+		t.Errorf("AttachedGroupUsers = %q, want %q", got, "alice bob ci-bot")
+	}
+	if got := strings.Join(p.AttachedAllUsers, " "); got != "alice bob ci-bot" {
+		t.Errorf("AttachedAllUsers = %q, want %q", got, "alice bob ci-bot")
+	}
+}
@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CheckPrincipals merges managed and inline policies for each user and role and saves them.
+func (c *PolicyChecker) CheckPrincipals() error {
+	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
+		return err
+	}
+
+	list, err := c.CollectPrincipals()
+	if err != nil {
+		return err
+	}
+	list, suppressed, err := c.suppressPrincipals(list)
+	if err != nil {
+		return err
+	}
+	if err := c.SavePrincipals(list); err != nil {
+		return err
+	}
+	return c.finishCheck(CheckTypePrincipal, len(list), nil, suppressed)
+}
+
+// CollectPrincipals fetches managed and inline policies and merges them for each user and role.
+func (c *PolicyChecker) CollectPrincipals() ([]*Principal, error) {
+	policies, err := c.CollectPolicies()
+	if err != nil {
+		return nil, err
+	}
+	inlinePolicies, err := c.CollectInlinePolicies()
+	if err != nil {
+		return nil, err
+	}
+	return buildPrincipals(policies, inlinePolicies), nil
+}
+
+// SavePrincipals saves the users and roles with the permissions to the output file.
+func (c *PolicyChecker) SavePrincipals(list []*Principal) error {
+	c.loggingInfo("invoking `SavePrincipals` size:[%d] ...", len(list))
+
+	f, err := c.newFileHandler()
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"entity_type",
+		"entity_name",
+		"group",
+		"policy",
+		"allowed_action",
+		"permission",
+	}
+
+	lines := make([][]string, len(list))
+	for i, p := range list {
+		perms := p.GetPermissions()
+		permLines := make([]string, 0, len(perms))
+		for _, v := range perms {
+			byt, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				continue
+			}
+			permLines = append(permLines, string(byt))
+		}
+		lines[i] = []string{
+			p.Type,
+			p.Name,
+			strings.Join(p.GetGroups(), "\n"),
+			strings.Join(p.GetPolicies(), "\n"),
+			strings.Join(p.GetAllowedActions(), "\n"),
+			strings.Join(permLines, "\n"),
+		}
+	}
+	return f.WriteAll(headers, lines)
+}
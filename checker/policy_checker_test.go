@@ -0,0 +1,67 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUniqueAndSort(t *testing.T) {
+	tests := []struct {
+		list []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{}, []string{}},
+		{[]string{"bob", "alice", "bob", "ci-bot", "alice"}, []string{"alice", "bob", "ci-bot"}},
+		{[]string{"b", "B", "a"}, []string{"B", "a", "b"}},
+	}
+	for _, tt := range tests {
+		got := uniqueAndSort(tt.list)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+			t.Errorf("uniqueAndSort(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestWarnUncataloguedServices(t *testing.T) {
+	c, buf := newTestChecker(t, Config{AccessLevel: "write"})
+	list, err := c.CollectInlinePolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var p *AwsPolicy
+	for _, v := range list {
+		if v.PolicyName == "etl-jobs" {
+			p = v
+		}
+	}
+	if p == nil {
+		t.Fatal("etl-jobs is not in the results")
+	}
+	if !containsString(p.ExpandedActions, "glue:*"+uncataloguedActionSuffix) {
+		t.Errorf("ExpandedActions = %q, want glue:* flagged", p.ExpandedActions)
+	}
+	if !containsString(p.ExpandedActions, "s3:PutObject") {
+		t.Errorf("ExpandedActions = %q, want s3:PutObject", p.ExpandedActions)
+	}
+
+	// the warning is once per service.
+	_, _ = c.CollectInlinePolicies()
+	if n := strings.Count(buf.String(), "Service:[glue]"); n != 1 {
+		t.Errorf("glue is warned %d times, want 1: %s", n, buf.String())
+	}
+	if strings.Contains(buf.String(), "Service:[s3]") {
+		t.Errorf("s3 is warned: %s", buf.String())
+	}
+}
+
+// containsString checks if the list contains the string.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
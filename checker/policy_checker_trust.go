@@ -0,0 +1,125 @@
+package checker
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// CheckTrust checks trust policies of the roles and saves who can assume each role.
+func (c *PolicyChecker) CheckTrust() error {
+	if err := checkIsDir(c.config.GetOutputFile()); err != nil {
+		return err
+	}
+
+	list, err := c.CollectTrusts()
+	if err != nil {
+		return err
+	}
+	suppressed, err := c.suppressTrustIssues(list)
+	if err != nil {
+		return err
+	}
+	if err := c.SaveTrusts(list); err != nil {
+		return err
+	}
+	// the issues are the findings, since all of the roles are in the results.
+	severities := []Severity{}
+	for _, t := range list {
+		for _, v := range t.Issues {
+			severities = append(severities, v.Rule.Severity)
+		}
+	}
+	return c.finishCheck(CheckTypeTrust, len(severities), severities, suppressed)
+}
+
+// CollectTrusts fetches roles and checks the trust policies.
+func (c *PolicyChecker) CollectTrusts() ([]*RoleTrust, error) {
+	roles, err := c.fetchRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	c.loggingInfo("invoking `newRoleTrust` size:[%d] ...", len(roles))
+	result := make([]*RoleTrust, 0, len(roles))
+	for _, r := range roles {
+		if t := c.newRoleTrust(r); t != nil {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// newRoleTrust parses the trust policy of the role by the config.
+// It returns nil when the trust policy is invalid.
+func (c *PolicyChecker) newRoleTrust(role iam.Role) *RoleTrust {
+	t, err := newRoleTrust(role, c.config.GetTrustedAccounts(), DefaultTrustRules())
+	if err != nil {
+		c.addFetchError(FetchError{Func: "newRoleTrust", Error: err.Error(), Entity: entityRole + "/" + role.RoleName})
+		return nil
+	}
+	return t
+}
+
+// SaveTrusts saves principals of the trust policies to the output file.
+func (c *PolicyChecker) SaveTrusts(list []*RoleTrust) error {
+	c.loggingInfo("invoking `SaveTrusts` size:[%d] ...", len(list))
+
+	f, err := c.newFileHandler()
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"role_name",
+		"role_arn",
+		"principal_type",
+		"principal",
+		"action",
+		"condition",
+		"identity",
+		"issue",
+	}
+
+	lines := make([][]string, 0, len(list))
+	for _, t := range list {
+		if len(t.Principals) == 0 {
+			lines = append(lines, []string{t.RoleName, t.RoleARN, "", "", "", "", "", ""})
+			continue
+		}
+
+		for _, p := range t.Principals {
+			var cond string
+			if len(p.Conditions) != 0 {
+				b, _ := json.MarshalIndent(p.Conditions, "", "  ")
+				cond = string(b)
+			}
+			issues := t.getIssuesOf(p)
+			issueLines := make([]string, len(issues))
+			for i, v := range issues {
+				issueLines[i] = v.Rule.Severity.String() + ": " + v.Rule.Name
+			}
+			identities := make([]string, len(p.Identities))
+			for i, w := range p.Identities {
+				identities[i] = w.String()
+			}
+
+			lines = append(lines, []string{
+				t.RoleName,
+				t.RoleARN,
+				p.Type,
+				p.Name,
+				strings.Join(p.Actions, "\n"),
+				cond,
+				strings.Join(identities, "\n"),
+				strings.Join(issueLines, "\n"),
+			})
+		}
+	}
+	return f.WriteAll(headers, lines)
+}
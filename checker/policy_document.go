@@ -0,0 +1,201 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	effectAllow = "Allow"
+	effectDeny  = "Deny"
+)
+
+// PolicyDocument contains permission data of a policy.
+type PolicyDocument struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// NewPolicyDocumentFromDocument returns initialized PolicyDocument from URL-encoded document of API response.
+func NewPolicyDocumentFromDocument(document string) (PolicyDocument, error) {
+	s, err := url.QueryUnescape(document)
+	if err != nil {
+		return PolicyDocument{}, err
+	}
+	return NewPolicyDocumentFromJSONString(s)
+}
+
+// NewPolicyDocumentFromJSONString returns initialized PolicyDocument from JSON data.
+func NewPolicyDocumentFromJSONString(data string) (PolicyDocument, error) {
+	p := PolicyDocument{}
+	err := json.Unmarshal([]byte(data), &p)
+	return p, err
+}
+
+// UnmarshalJSON converts from json to *PolicyDocument.
+// Statement can be a single object or a list of objects.
+func (p *PolicyDocument) UnmarshalJSON(data []byte) error {
+	var d struct {
+		Version   string          `json:"Version"`
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+
+	p.Version = d.Version
+	p.Statement = nil
+	if len(d.Statement) == 0 || string(d.Statement) == "null" {
+		return nil
+	}
+	if d.Statement[0] != '[' {
+		s := Statement{}
+		if err := json.Unmarshal(d.Statement, &s); err != nil {
+			return err
+		}
+		p.Statement = []Statement{s}
+		return nil
+	}
+	return json.Unmarshal(d.Statement, &p.Statement)
+}
+
+// Statement represents statement of iam policy.
+type Statement struct {
+	Sid          string          `json:"Sid,omitempty"`
+	Effect       string          `json:"Effect"`
+	Principal    PrincipalValues `json:"Principal,omitempty"`
+	NotPrincipal PrincipalValues `json:"NotPrincipal,omitempty"`
+	Action       stringList      `json:"Action,omitempty"`
+	NotAction    stringList      `json:"NotAction,omitempty"`
+	Resource     stringList      `json:"Resource,omitempty"`
+	NotResource  stringList      `json:"NotResource,omitempty"`
+	Condition    Condition       `json:"Condition,omitempty"`
+}
+
+// IsAllow checks that effect is allow.
+func (s Statement) IsAllow() bool {
+	return s.Effect == effectAllow
+}
+
+// IsDeny checks that effect is deny.
+func (s Statement) IsDeny() bool {
+	return s.Effect == effectDeny
+}
+
+// HasCondition checks if the statement has Condition block.
+func (s Statement) HasCondition() bool {
+	return len(s.Condition) != 0
+}
+
+// coversAction checks if the statement applies to every action matched by the given action.
+func (s Statement) coversAction(action string) bool {
+	action = strings.ToLower(action)
+	if len(s.NotAction) != 0 {
+		for _, v := range s.NotAction {
+			if overlapWildcard(strings.ToLower(v), action) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range s.Action {
+		if matchWildcard(strings.ToLower(v), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversResource checks if the statement applies to every resource matched by the given resource.
+func (s Statement) coversResource(resource string) bool {
+	if len(s.NotResource) != 0 {
+		for _, v := range s.NotResource {
+			if overlapWildcard(v, resource) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range s.Resource {
+		if matchWildcard(v, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+// Condition represents Condition block of statement.
+// (e.g. {"Bool": {"aws:MultiFactorAuthPresent": ["true"]}})
+type Condition map[string]map[string]stringList
+
+// HasKey checks if the condition contains the condition key.
+// Condition key is case-insensitive.
+func (c Condition) HasKey(key string) bool {
+	for _, kv := range c {
+		for k := range kv {
+			if strings.EqualFold(k, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PrincipalValues represents Principal block of statement in resource-based policy (e.g. trust policy of the role).
+// The key is principal type. (AWS, Service, Federated or CanonicalUser)
+// (e.g. {"AWS": ["arn:aws:iam::123456789012:root"], "Service": ["ec2.amazonaws.com"]})
+type PrincipalValues map[string]stringList
+
+// UnmarshalJSON converts from json to PrincipalValues.
+// `"Principal": "*"` is converted into `{"AWS": ["*"]}`.
+func (p *PrincipalValues) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*p = PrincipalValues{principalTypeAWS: stringList{s}}
+		return nil
+	}
+
+	var m map[string]stringList
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*p = m
+	return nil
+}
+
+// stringList is a list of string which accepts a single value in JSON.
+type stringList []string
+
+// UnmarshalJSON converts from json value or list of value to stringList.
+// Non-string values (e.g. true, 100) are converted into string.
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		*l = nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, vv := range v {
+			list[i] = toString(vv)
+		}
+		*l = list
+	default:
+		*l = []string{toString(v)}
+	}
+	return nil
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
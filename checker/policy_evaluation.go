@@ -0,0 +1,216 @@
+package checker
+
+import (
+	"strings"
+)
+
+// hasTargetPermission checks if the given Allow statement grants target permissions,
+// and the permissions are not denied by the Deny statements.
+func hasTargetPermission(c Config, s Statement, denies []Statement) bool {
+	if !s.IsAllow() {
+		return false
+	}
+	if c.hasAccessLevelRule() && !hasAccessLevelPermission(c, s, denies) {
+		return false
+	}
+	if c.hasFilterRule() && !c.GetFilter().Match(c, s, denies) {
+		return false
+	}
+	if c.ShowAllPolicy || !c.hasTargetRule() {
+		// matched by access levels or the filter, or all of the statements except the excluded ones.
+		return !c.hasExclusionRule() || !isExcludedStatement(s, c.getExclusionStatements())
+	}
+
+	useWildcard := c.MatchStatementWildcard
+	svc := c.GetTargetActionServices()
+	if svc.hasService() {
+		return hasAllowedAction(s, svc.grantedActions(s), denies)
+	}
+
+	if hasAllowedResource(s, grantedResources(s, c.GetTargetResources(), useWildcard), denies) {
+		return true
+	}
+	return hasAllowedAction(s, grantedActions(s, c.GetTargetActions(), useWildcard), denies)
+}
+
+// hasAccessLevelPermission checks if the statement grants the actions with the target access levels in the action catalog.
+// When target services are set, only the actions of the services are checked.
+func hasAccessLevelPermission(c Config, s Statement, denies []Statement) bool {
+	catalog, _ := c.GetActionCatalog()
+	svc := c.GetTargetActionServices()
+	for _, e := range catalog.grantedActions(s, denies, c.GetAccessLevels()) {
+		if !svc.hasService() || svc.isTargetAction(e.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCondition checks if the Condition block of the statement satisfies condition rules from config.
+func matchCondition(c Config, s Statement) bool {
+	if c.OnlyUnconditional && s.HasCondition() {
+		return false
+	}
+
+	keys := c.GetMissingConditionKeys()
+	if len(keys) == 0 {
+		return true
+	}
+	for _, key := range keys {
+		if !s.Condition.HasKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantedActions returns actions of the statement matched with the targets.
+// When the action is granted by wildcard or NotAction in the statement, the target is returned instead.
+// NotAction works as the wildcard in the statement, so it's used only when useStatementWildcard is true.
+func grantedActions(s Statement, targets []string, useStatementWildcard bool) []string {
+	var result []string
+	for _, target := range targets {
+		if len(s.NotAction) != 0 {
+			// NotAction grants all of the actions except listed ones.
+			if useStatementWildcard && !isExcludedByNotAction(s, toActionPattern(target)) {
+				result = append(result, toActionPattern(target))
+			}
+			continue
+		}
+
+		for _, a := range s.Action {
+			switch {
+			case matchAction(a, target, false):
+				result = append(result, a)
+			case matchAction(a, target, useStatementWildcard):
+				result = append(result, toActionPattern(target))
+			}
+		}
+	}
+	return result
+}
+
+// grantedResources returns resources of the statement matched with the targets.
+// When the resource is granted by wildcard or NotResource in the statement, the target is returned instead.
+// NotResource works as the wildcard in the statement, so it's used only when useStatementWildcard is true.
+func grantedResources(s Statement, targets []string, useStatementWildcard bool) []string {
+	var result []string
+	for _, target := range targets {
+		if len(s.NotResource) != 0 {
+			// NotResource grants all of the resources except listed ones.
+			if useStatementWildcard && !isExcludedByNotResource(s, toWildcardPattern(target)) {
+				result = append(result, toWildcardPattern(target))
+			}
+			continue
+		}
+
+		for _, r := range s.Resource {
+			switch {
+			case matchResource(r, target, false):
+				result = append(result, r)
+			case matchResource(r, target, useStatementWildcard):
+				result = append(result, toWildcardPattern(target))
+			}
+		}
+	}
+	return result
+}
+
+// isExcludedByNotAction checks if all of the actions matched by the pattern are listed in NotAction.
+func isExcludedByNotAction(s Statement, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, v := range s.NotAction {
+		if matchWildcard(strings.ToLower(v), pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcludedByNotResource checks if all of the resources matched by the pattern are listed in NotResource.
+func isExcludedByNotResource(s Statement, pattern string) bool {
+	for _, v := range s.NotResource {
+		if matchWildcard(v, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllowedAction checks if any of the granted actions are not denied.
+func hasAllowedAction(s Statement, actions []string, denies []Statement) bool {
+	for _, a := range actions {
+		if !isDeniedAction(s, a, denies) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllowedResource checks if any of the granted resources are not denied.
+func hasAllowedResource(s Statement, resources []string, denies []Statement) bool {
+	for _, r := range resources {
+		if !isDeniedResource(s, r, denies) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeniedAction checks if the action is denied on all of the resources in the Allow statement.
+func isDeniedAction(allow Statement, action string, denies []Statement) bool {
+	for _, d := range denies {
+		if d.coversAction(action) && coversAllResources(d, allow) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeniedResource checks if the resource is denied for all of the actions in the Allow statement.
+func isDeniedResource(allow Statement, resource string, denies []Statement) bool {
+	for _, d := range denies {
+		if d.coversResource(resource) && coversAllActions(d, allow) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversAllResources checks if the Deny statement covers all of the resources in the Allow statement.
+func coversAllResources(deny, allow Statement) bool {
+	if len(allow.NotResource) != 0 {
+		return deny.coversResource("*")
+	}
+	for _, r := range allow.Resource {
+		if !deny.coversResource(r) {
+			return false
+		}
+	}
+	return len(allow.Resource) != 0
+}
+
+// coversAllActions checks if the Deny statement covers all of the actions in the Allow statement.
+func coversAllActions(deny, allow Statement) bool {
+	if len(allow.NotAction) != 0 {
+		return deny.coversAction("*")
+	}
+	for _, a := range allow.Action {
+		if !deny.coversAction(a) {
+			return false
+		}
+	}
+	return len(allow.Action) != 0
+}
+
+// getDenyStatements returns Deny statements from the list.
+// Deny statements with Condition are not used, because they do not always deny the permissions.
+func getDenyStatements(statements []Statement) []Statement {
+	var result []Statement
+	for _, s := range statements {
+		if s.IsDeny() && !s.HasCondition() {
+			result = append(result, s)
+		}
+	}
+	return result
+}
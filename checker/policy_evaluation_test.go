@@ -0,0 +1,247 @@
+package checker
+
+import (
+	"testing"
+)
+
+// matchDocument checks if any Allow statement in the policy document matches the rules of config.
+func matchDocument(t *testing.T, conf Config, doc string) bool {
+	t.Helper()
+	pd, err := NewPolicyDocumentFromJSONString(doc)
+	if err != nil {
+		t.Fatalf("invalid policy document: %v", err)
+	}
+	c := &PolicyChecker{config: conf}
+	return c.hasTargetPermission(pd.Statement)
+}
+
+func TestHasTargetPermissionDeny(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		doc    string
+		want   bool
+	}{
+		{
+			name:   "action denied by Deny statement",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:Delete*","Resource":"*"}
+			]}`,
+			want: false,
+		},
+		{
+			name:   "action starting with the denied one",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "other action is not denied",
+			action: "s3:PutObject",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "action denied by Deny wildcard",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:DeleteBucket","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:Delete*","Resource":"*"}
+			]}`,
+			want: false,
+		},
+		{
+			name:   "Deny on part of the resources",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:DeleteBucket","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"arn:aws:s3:::prod-*"}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "Deny with Condition",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:DeleteBucket","Resource":"*"},
+				{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"false"}}}
+			]}`,
+			want: true,
+		},
+		{
+			name:   "Deny statement only",
+			action: "s3:DeleteBucket",
+			doc:    `{"Statement":[{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			want:   false,
+		},
+		{
+			name:   "NotAction denies all except listed ones",
+			action: "s3:DeleteBucket",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+				{"Effect":"Deny","NotAction":"s3:Get*","Resource":"*"}
+			]}`,
+			want: false,
+		},
+		{
+			name:   "NotAction in Deny does not deny listed ones",
+			action: "s3:GetObject",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"s3:*","Resource":"*"},
+				{"Effect":"Deny","NotAction":"s3:Get*","Resource":"*"}
+			]}`,
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Config{TargetAction: tt.action, MatchStatementWildcard: true}
+			if got := matchDocument(t, conf, tt.doc); got != tt.want {
+				t.Errorf("action %q = %v, want %v", tt.action, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasTargetPermissionNotAction(t *testing.T) {
+	const (
+		notIAM     = `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`
+		notIAMDeny = `{"Statement":[
+			{"Effect":"Allow","NotAction":"iam:*","Resource":"*"},
+			{"Effect":"Deny","Action":"s3:*","Resource":"*"}
+		]}`
+		s3All = `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`
+	)
+
+	tests := []struct {
+		name string
+		conf Config
+		doc  string
+		want bool
+	}{
+		{"action without --wildcard", Config{TargetAction: "s3:GetObject"}, notIAM, false},
+		{"action with --wildcard", Config{TargetAction: "s3:GetObject", MatchStatementWildcard: true}, notIAM, true},
+		{"same as Action wildcard without --wildcard", Config{TargetAction: "s3:GetObject"}, s3All, false},
+		{"same as Action wildcard with --wildcard", Config{TargetAction: "s3:GetObject", MatchStatementWildcard: true}, s3All, true},
+		{"listed action", Config{TargetAction: "iam:CreateUser", MatchStatementWildcard: true}, notIAM, false},
+		{"denied action", Config{TargetAction: "s3:GetObject", MatchStatementWildcard: true}, notIAMDeny, false},
+		{"service without --wildcard", Config{TargetActionService: "s3"}, notIAM, false},
+		{"service with --wildcard", Config{TargetActionService: "s3", MatchStatementWildcard: true}, notIAM, true},
+		{"listed service", Config{TargetActionService: "iam", MatchStatementWildcard: true}, notIAM, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchDocument(t, tt.conf, tt.doc); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasTargetPermissionNotResource(t *testing.T) {
+	const (
+		notSecret = `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","NotResource":"arn:aws:s3:::secret-*"}]}`
+		allDenied = `{"Statement":[
+			{"Effect":"Allow","Action":"s3:GetObject","NotResource":"arn:aws:s3:::secret-*"},
+			{"Effect":"Deny","Action":"s3:*","Resource":"arn:aws:s3:::public-*"}
+		]}`
+	)
+
+	tests := []struct {
+		name     string
+		resource string
+		wildcard bool
+		doc      string
+		want     bool
+	}{
+		{"without --wildcard", "arn:aws:s3:::public-a", false, notSecret, false},
+		{"with --wildcard", "arn:aws:s3:::public-a", true, notSecret, true},
+		{"listed resource", "arn:aws:s3:::secret-*", true, notSecret, false},
+		{"denied resource", "arn:aws:s3:::public-*", true, allDenied, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := Config{TargetResource: tt.resource, MatchStatementWildcard: tt.wildcard}
+			if got := matchDocument(t, conf, tt.doc); got != tt.want {
+				t.Errorf("resource %q = %v, want %v", tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrantedActions(t *testing.T) {
+	tests := []struct {
+		name                 string
+		statement            Statement
+		target               string
+		useStatementWildcard bool
+		want                 []string
+	}{
+		{
+			name:      "action in the statement",
+			statement: Statement{Effect: effectAllow, Action: stringList{"s3:GetObject", "s3:PutObject"}},
+			target:    "s3:PutObject",
+			want:      []string{"s3:PutObject"},
+		},
+		{
+			name:      "substring of the action",
+			statement: Statement{Effect: effectAllow, Action: stringList{"s3:DeleteBucketPolicy"}},
+			target:    "s3:DeleteBucket",
+			want:      []string{"s3:DeleteBucketPolicy"},
+		},
+		{
+			name:      "statement wildcard without flag",
+			statement: Statement{Effect: effectAllow, Action: stringList{"s3:*"}},
+			target:    "s3:GetObject",
+			want:      nil,
+		},
+		{
+			name:                 "statement wildcard returns the target",
+			statement:            Statement{Effect: effectAllow, Action: stringList{"s3:*"}},
+			target:               "s3:Get*",
+			useStatementWildcard: true,
+			want:                 []string{"s3:Get*"},
+		},
+		{
+			name:      "NotAction without flag",
+			statement: Statement{Effect: effectAllow, NotAction: stringList{"iam:*"}},
+			target:    "s3:GetObject",
+			want:      nil,
+		},
+		{
+			name:                 "NotAction returns the target",
+			statement:            Statement{Effect: effectAllow, NotAction: stringList{"iam:*"}},
+			target:               "s3:Get*",
+			useStatementWildcard: true,
+			want:                 []string{"s3:Get*"},
+		},
+		{
+			name:                 "NotAction excludes the target",
+			statement:            Statement{Effect: effectAllow, NotAction: stringList{"s3:*"}},
+			target:               "s3:GetObject",
+			useStatementWildcard: true,
+			want:                 nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := grantedActions(tt.statement, []string{tt.target}, tt.useStatementWildcard)
+			if len(got) != len(tt.want) {
+				t.Fatalf("grantedActions() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("grantedActions() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
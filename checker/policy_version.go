@@ -0,0 +1,113 @@
+package checker
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// getBroaderPermissions returns permissions of the version which are not allowed by the default version.
+// (e.g. `Allow s3:* on *`, `Deny iam:* on * is removed`)
+func getBroaderPermissions(version, defaultVersion PolicyDocument) []string {
+	var allows, denies []Statement
+	for _, s := range defaultVersion.Statement {
+		switch {
+		case s.IsAllow():
+			allows = append(allows, s)
+		case s.IsDeny():
+			denies = append(denies, s)
+		}
+	}
+
+	var result []string
+	for _, s := range version.Statement {
+		if !s.IsAllow() {
+			continue
+		}
+		for _, a := range getStatementActions(s) {
+			for _, r := range getStatementResources(s) {
+				if !isCoveredByStatements(s, a, r, allows) {
+					result = append(result, fmt.Sprintf("%s %s on %s", effectAllow, a, r))
+				}
+			}
+		}
+	}
+
+	// removed Deny statements also broaden the permissions.
+	for _, d := range denies {
+		if !hasSameStatement(version.Statement, d) {
+			for _, a := range getStatementActions(d) {
+				for _, r := range getStatementResources(d) {
+					result = append(result, fmt.Sprintf("%s %s on %s is removed", effectDeny, a, r))
+				}
+			}
+		}
+	}
+	return result
+}
+
+// isCoveredByStatements checks if the action and the resource of the statement are allowed by any of the statements.
+func isCoveredByStatements(s Statement, action, resource string, list []Statement) bool {
+	for _, v := range list {
+		if v.HasCondition() && !reflect.DeepEqual(v.Condition, s.Condition) {
+			continue
+		}
+
+		switch {
+		case len(s.NotAction) != 0 || len(s.NotResource) != 0:
+			// compare NotAction/NotResource as it is.
+			if isSameNotActionAndResource(v, s) || (v.coversAction("*") && v.coversResource("*")) {
+				return true
+			}
+		case v.coversAction(action) && v.coversResource(resource):
+			return true
+		}
+	}
+	return false
+}
+
+// isSameNotActionAndResource checks if the statements have the same Action/NotAction and Resource/NotResource.
+func isSameNotActionAndResource(a, b Statement) bool {
+	return equalFoldList(a.Action, b.Action) &&
+		equalFoldList(a.NotAction, b.NotAction) &&
+		reflect.DeepEqual(a.Resource, b.Resource) &&
+		reflect.DeepEqual(a.NotResource, b.NotResource)
+}
+
+// hasSameStatement checks if the list contains the statement with the same permissions.
+func hasSameStatement(list []Statement, s Statement) bool {
+	for _, v := range list {
+		if v.Effect == s.Effect && isSameNotActionAndResource(v, s) && reflect.DeepEqual(v.Condition, s.Condition) {
+			return true
+		}
+	}
+	return false
+}
+
+// getStatementActions returns actions of the statement. NotAction is shown as `NotAction(iam:*)`.
+func getStatementActions(s Statement) []string {
+	if len(s.NotAction) != 0 {
+		return []string{"NotAction(" + strings.Join(s.NotAction, ", ") + ")"}
+	}
+	return s.Action
+}
+
+// getStatementResources returns resources of the statement. NotResource is shown as `NotResource(arn:aws:s3:::*)`.
+func getStatementResources(s Statement) []string {
+	if len(s.NotResource) != 0 {
+		return []string{"NotResource(" + strings.Join(s.NotResource, ", ") + ")"}
+	}
+	return s.Resource
+}
+
+func equalFoldList(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetBroaderPermissions(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        string
+		defaultVersion string
+		want           string
+	}{
+		{
+			name:           "same permissions",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			want:           "",
+		},
+		{
+			name:           "narrower permissions",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:Get*","Resource":"*"}]}`,
+			want:           "",
+		},
+		{
+			name:           "added action",
+			version:        `{"Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			want:           "Allow s3:PutObject on *",
+		},
+		{
+			name:           "wildcard action",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:Get*","Resource":"*"}]}`,
+			want:           "Allow s3:* on *",
+		},
+		{
+			name:           "broader resource",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::a/*"}]}`,
+			want:           "Allow s3:GetObject on arn:aws:s3:::*",
+		},
+		{
+			name:           "removed Deny",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"},{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			want:           "Deny s3:DeleteBucket on * is removed",
+		},
+		{
+			name:           "kept Deny",
+			version:        `{"Statement":[{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"},{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"},{"Effect":"Deny","Action":"s3:DeleteBucket","Resource":"*"}]}`,
+			want:           "",
+		},
+		{
+			name:           "removed condition",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"true"}}}]}`,
+			want:           "Allow s3:GetObject on *",
+		},
+		{
+			name:           "same condition",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"true"}}}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*","Condition":{"Bool":{"aws:SecureTransport":"true"}}}]}`,
+			want:           "",
+		},
+		{
+			name:           "NotAction",
+			version:        `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`,
+			want:           "Allow NotAction(iam:*) on *",
+		},
+		{
+			name:           "same NotAction",
+			version:        `{"Statement":[{"Effect":"Allow","NotAction":"IAM:*","Resource":"*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`,
+			want:           "",
+		},
+		{
+			name:           "NotResource covered by admin",
+			version:        `{"Statement":[{"Effect":"Allow","Action":"s3:*","NotResource":"arn:aws:s3:::secret/*"}]}`,
+			defaultVersion: `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+			want:           "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version := newTestPolicy(t, "version", tt.version).Policy
+			defaultVersion := newTestPolicy(t, "default", tt.defaultVersion).Policy
+			if got := strings.Join(getBroaderPermissions(version, defaultVersion), "\n"); got != tt.want {
+				t.Errorf("getBroaderPermissions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectPoliciesAllVersions(t *testing.T) {
+	c, _ := newTestChecker(t, Config{ShowAllPolicy: true, AllVersions: true})
+	list, err := c.CollectPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	var broader []string
+	for _, p := range list {
+		if p.PolicyName != "DeveloperAccess" {
+			continue
+		}
+		versions = append(versions, p.VersionID)
+		if p.NonDefaultVersion {
+			broader = p.BroaderPermissions
+		}
+	}
+	if got := strings.Join(versions, " "); got != "v2 v1" {
+		t.Errorf("versions = %q, want %q", got, "v2 v1")
+	}
+	want := "Allow iam:PassRole on *\nDeny s3:DeleteBucket on * is removed"
+	if got := strings.Join(broader, "\n"); got != want {
+		t.Errorf("BroaderPermissions = %q, want %q", got, want)
+	}
+}
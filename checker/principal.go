@@ -0,0 +1,245 @@
+package checker
+
+import (
+	"sort"
+	"strings"
+)
+
+// Principal contains User or Role with the policies granted to it.
+type Principal struct {
+	Type     string            `json:"type"`
+	Name     string            `json:"name"`
+	Policies []PrincipalPolicy `json:"policies"`
+}
+
+// PrincipalPolicy contains a policy granted to the principal and where it comes from.
+type PrincipalPolicy struct {
+	Policy *AwsPolicy `json:"policy"`
+	Group  string     `json:"group,omitempty"` // group name when the policy is granted via the group
+}
+
+// PolicyGrant is a policy granting the action to the principal, and the scope of the Allow statement.
+type PolicyGrant struct {
+	PrincipalPolicy
+	Resources   []string `json:"resources,omitempty"`   // resources of the statement when it does not allow all resources
+	Conditional bool     `json:"conditional,omitempty"` // the statement has Condition block
+}
+
+// newPolicyGrant returns the grant of the Allow statement in the policy.
+// `NotResource` is shown as `NotResource(arn:aws:iam::*:role/admin)`.
+func newPolicyGrant(pp PrincipalPolicy, s Statement) PolicyGrant {
+	g := PolicyGrant{
+		PrincipalPolicy: pp,
+		Conditional:     s.HasCondition(),
+	}
+	switch {
+	case len(s.NotResource) != 0:
+		g.Resources = []string{"NotResource(" + strings.Join(s.NotResource, ", ") + ")"}
+	case !grantsAllResources(s):
+		g.Resources = s.Resource
+	}
+	return g
+}
+
+// IsRestricted checks if the grant is limited by the resources or Condition.
+func (g PolicyGrant) IsRestricted() bool {
+	return len(g.Resources) != 0 || g.Conditional
+}
+
+// String returns the policy name with the scope. (e.g. `pass-role (inline) [resource: arn:aws:iam::012345678901:role/app] [conditional]`)
+func (g PolicyGrant) String() string {
+	name := g.PrincipalPolicy.String()
+	if len(g.Resources) != 0 {
+		name += " [resource: " + strings.Join(g.Resources, ", ") + "]"
+	}
+	if g.Conditional {
+		name += " [conditional]"
+	}
+	return name
+}
+
+// PrincipalPermission is a statement granted to the principal and the policy granting it.
+type PrincipalPermission struct {
+	ResourceAction
+	Policy string `json:"policy"` // policy name with the source. (e.g. `AdminPolicy (group:developers)`)
+}
+
+// GetStatements returns all of the statements in the policies.
+func (p Principal) GetStatements() []Statement {
+	var result []Statement
+	for _, pp := range p.Policies {
+		result = append(result, pp.Policy.Policy.Statement...)
+	}
+	return result
+}
+
+// GetPermissions returns all of the statements with the policies granting them.
+func (p Principal) GetPermissions() []PrincipalPermission {
+	var result []PrincipalPermission
+	for _, pp := range p.Policies {
+		for _, ra := range pp.Policy.PolicyResourceActions {
+			result = append(result, PrincipalPermission{
+				ResourceAction: ra,
+				Policy:         pp.String(),
+			})
+		}
+	}
+	return result
+}
+
+// GetAllowedActions returns actions in Allow statements with the policies granting them.
+// (e.g. `s3:GetObject: ReadOnlyPolicy, s3-admin (inline) (group:developers)`)
+// `NotAction` is shown as `NotAction(iam:*)`.
+func (p Principal) GetAllowedActions() []string {
+	m := make(map[string][]string)
+	var actions []string
+	add := func(action, policy string) {
+		if _, ok := m[action]; !ok {
+			actions = append(actions, action)
+		}
+		for _, v := range m[action] {
+			if v == policy {
+				return
+			}
+		}
+		m[action] = append(m[action], policy)
+	}
+
+	for _, pp := range p.Policies {
+		for _, s := range pp.Policy.Policy.Statement {
+			if !s.IsAllow() {
+				continue
+			}
+			for _, a := range s.Action {
+				add(a, pp.String())
+			}
+			if len(s.NotAction) != 0 {
+				add("NotAction("+strings.Join(s.NotAction, ", ")+")", pp.String())
+			}
+		}
+	}
+
+	sort.Slice(actions, func(i, j int) bool {
+		return strings.ToLower(actions[i]) < strings.ToLower(actions[j])
+	})
+	result := make([]string, len(actions))
+	for i, a := range actions {
+		result[i] = a + ": " + strings.Join(m[a], ", ")
+	}
+	return result
+}
+
+// GetGroups returns the group names which the principal belongs to and has policies from.
+func (p Principal) GetGroups() []string {
+	var result []string
+	for _, pp := range p.Policies {
+		if pp.Group == "" {
+			continue
+		}
+		exists := false
+		for _, g := range result {
+			if g == pp.Group {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			result = append(result, pp.Group)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetPolicies returns the policy names with the source. (e.g. `AdminPolicy (group:developers)`)
+func (p Principal) GetPolicies() []string {
+	result := make([]string, len(p.Policies))
+	for i, pp := range p.Policies {
+		result[i] = pp.String()
+	}
+	return result
+}
+
+// GrantedBy returns the policies which allow the action and it's not denied by any policy of the principal.
+// When several statements of the policy allow the action, the statement without restriction is used.
+func (p Principal) GrantedBy(action string) []PolicyGrant {
+	denies := getDenyStatements(p.GetStatements())
+
+	var result []PolicyGrant
+	for _, pp := range p.Policies {
+		var grant *PolicyGrant
+		for _, s := range pp.Policy.Policy.Statement {
+			if !s.IsAllow() || !grantsAction(s, action) || isDeniedAction(s, action, denies) {
+				continue
+			}
+			if grant == nil || grant.IsRestricted() {
+				g := newPolicyGrant(pp, s)
+				grant = &g
+			}
+		}
+		if grant != nil {
+			result = append(result, *grant)
+		}
+	}
+	return result
+}
+
+// hasUnrestrictedGrant checks if any of the grants is not limited by the resources or Condition.
+func hasUnrestrictedGrant(list []PolicyGrant) bool {
+	for _, g := range list {
+		if !g.IsRestricted() {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPrincipals creates User/Role list with managed and inline policies including policies from groups.
+// Group members must be filled in the policies.
+func buildPrincipals(lists ...[]*AwsPolicy) []*Principal {
+	m := make(map[string]*Principal)
+	add := func(typ, name string, pp PrincipalPolicy) {
+		key := typ + "/" + name
+		p, ok := m[key]
+		if !ok {
+			p = &Principal{
+				Type: typ,
+				Name: name,
+			}
+			m[key] = p
+		}
+		p.Policies = append(p.Policies, pp)
+	}
+
+	for _, list := range lists {
+		for _, policy := range list {
+			// non-default versions are not granted.
+			if policy.NonDefaultVersion {
+				continue
+			}
+			for _, name := range policy.AttachedUsers {
+				add(entityUser, name, PrincipalPolicy{Policy: policy})
+			}
+			for _, name := range policy.AttachedRoles {
+				add(entityRole, name, PrincipalPolicy{Policy: policy})
+			}
+			for _, g := range policy.AttachedGroups {
+				for _, name := range g.Users {
+					add(entityUser, name, PrincipalPolicy{Policy: policy, Group: g.Name})
+				}
+			}
+		}
+	}
+
+	result := make([]*Principal, 0, len(m))
+	for _, p := range m {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,280 @@
+package checker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Severity represents risk level of the rule.
+type Severity int
+
+// Severity list.
+const (
+	SeverityUnknown Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityLow:      "low",
+	SeverityMedium:   "medium",
+	SeverityHigh:     "high",
+	SeverityCritical: "critical",
+}
+
+// MarshalJSON converts Severity into the name.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Severity) String() string {
+	if v, ok := severityNames[s]; ok {
+		return v
+	}
+	return "unknown"
+}
+
+// ParseSeverity returns Severity from the name. (e.g. `high`)
+func ParseSeverity(name string) (Severity, error) {
+	for s, v := range severityNames {
+		if strings.EqualFold(v, name) {
+			return s, nil
+		}
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity: '%s'", name)
+}
+
+// Rule is a risk rule for the statement.
+type Rule struct {
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+	Supersedes  []string `json:"-"` // narrower rules not reported when this rule matches the same statement
+
+	// Match checks if the Allow statement is risky.
+	// denies are Deny statements in the same policy.
+	Match func(s Statement, denies []Statement) bool `json:"-"`
+}
+
+// DefaultRules returns built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:        "full-admin",
+			Severity:    SeverityCritical,
+			Description: "allows all actions on all resources (`*:*`)",
+			Supersedes:  []string{"iam-admin", "passrole-all", "assumerole-all", "write-all-resources"},
+			Match: func(s Statement, denies []Statement) bool {
+				return grantsActionOnAllResources(s, "*", denies) || grantsActionOnAllResources(s, "*:*", denies)
+			},
+		},
+		{
+			Name:        "iam-admin",
+			Severity:    SeverityHigh,
+			Description: "allows all IAM actions on all resources (`iam:*`)",
+			Supersedes:  []string{"passrole-all"},
+			Match: func(s Statement, denies []Statement) bool {
+				return grantsActionOnAllResources(s, "iam:*", denies)
+			},
+		},
+		{
+			Name:        "passrole-all",
+			Severity:    SeverityHigh,
+			Description: "allows `iam:PassRole` on all resources",
+			Match: func(s Statement, denies []Statement) bool {
+				return grantsActionOnAllResources(s, "iam:PassRole", denies)
+			},
+		},
+		{
+			Name:        "assumerole-all",
+			Severity:    SeverityHigh,
+			Description: "allows `sts:AssumeRole` on all resources",
+			Match: func(s Statement, denies []Statement) bool {
+				return grantsActionOnAllResources(s, "sts:AssumeRole", denies)
+			},
+		},
+		{
+			Name:        "write-all-resources",
+			Severity:    SeverityMedium,
+			Description: "allows write actions on all resources (`Resource: *`)",
+			Match: func(s Statement, denies []Statement) bool {
+				if !grantsAllResources(s) {
+					return false
+				}
+				if len(s.NotAction) != 0 {
+					return !isDeniedAction(s, "*", denies)
+				}
+				for _, a := range s.Action {
+					if isWriteAction(a) && !isDeniedAction(s, a, denies) {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		{
+			Name:        "allow-not-action",
+			Severity:    SeverityMedium,
+			Description: "allows all actions except listed ones by `NotAction`",
+			Match: func(s Statement, denies []Statement) bool {
+				return len(s.NotAction) != 0
+			},
+		},
+	}
+}
+
+// grantsActionOnAllResources checks if the statement allows the action on all resources and it's not denied.
+func grantsActionOnAllResources(s Statement, action string, denies []Statement) bool {
+	return grantsAllResources(s) && grantsAction(s, action) && !isDeniedAction(s, action, denies)
+}
+
+// grantsAction checks if the statement allows all of the actions matched by the given action.
+func grantsAction(s Statement, action string) bool {
+	if len(s.NotAction) != 0 {
+		return !isExcludedByNotAction(s, action) && !isPartlyExcludedByNotAction(s, action)
+	}
+
+	action = strings.ToLower(action)
+	for _, a := range s.Action {
+		if matchWildcard(strings.ToLower(a), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPartlyExcludedByNotAction checks if some of the actions matched by the pattern are listed in NotAction.
+func isPartlyExcludedByNotAction(s Statement, pattern string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, v := range s.NotAction {
+		if matchWildcard(pattern, strings.ToLower(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsAllResources checks if the statement allows `Resource: *`.
+func grantsAllResources(s Statement) bool {
+	for _, r := range s.Resource {
+		if r == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeActionPrefixes are prefixes of action names which modify resources.
+var writeActionPrefixes = []string{
+	"Add", "Associate", "Attach", "Cancel", "Change", "Copy", "Create", "Delete", "Deregister", "Detach", "Disable",
+	"Disassociate", "Enable", "Import", "Invoke", "Modify", "Publish", "Put", "Reboot", "Register", "Remove", "Replace",
+	"Reset", "Restore", "Revoke", "Run", "Send", "Set", "Start", "Stop", "Terminate", "Update", "Upload", "Write",
+}
+
+// isWriteAction checks if the action modifies resources by the name.
+// Service wildcard (e.g. `s3:*`) is treated as write action.
+func isWriteAction(action string) bool {
+	if action == "*" {
+		return true
+	}
+
+	parts := strings.SplitN(action, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	name := strings.ToLower(parts[1])
+	if name == "*" {
+		return true
+	}
+	for _, prefix := range writeActionPrefixes {
+		if strings.HasPrefix(name, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// supersedes checks if the rule supersedes the other rule.
+func (r Rule) supersedes(name string) bool {
+	for _, v := range r.Supersedes {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding is a result of the rule matched to the statement of the policy.
+type Finding struct {
+	Rule           Rule       `json:"rule"`
+	Severity       Severity   `json:"severity"`    // severity of the rule, lowered for the conditional statement
+	Conditional    bool       `json:"conditional"` // the statement has Condition block (e.g. MFA or source IP)
+	Policy         *AwsPolicy `json:"policy"`
+	StatementIndex int        `json:"statement_index"`
+	Statement      Statement  `json:"statement"`
+}
+
+// checkRules applies the rules to each Allow statement of the policies.
+// The rules superseded by the broader rule matched to the same statement are not reported. (e.g. `iam-admin` by `full-admin`)
+func checkRules(rules []Rule, list []*AwsPolicy) []Finding {
+	var result []Finding
+	for _, p := range list {
+		denies := getDenyStatements(p.Policy.Statement)
+		for i, s := range p.Policy.Statement {
+			if !s.IsAllow() {
+				continue
+			}
+			for _, r := range matchRules(rules, s, denies) {
+				result = append(result, Finding{
+					Rule:           r,
+					Severity:       getRuleSeverity(r, s),
+					Conditional:    s.HasCondition(),
+					Policy:         p,
+					StatementIndex: i,
+					Statement:      s,
+				})
+			}
+		}
+	}
+	return result
+}
+
+// matchRules returns the rules matched to the statement, except the rules superseded by other matched rules.
+func matchRules(rules []Rule, s Statement, denies []Statement) []Rule {
+	var matched []Rule
+	for _, r := range rules {
+		if r.Match(s, denies) {
+			matched = append(matched, r)
+		}
+	}
+
+	var result []Rule
+	for _, r := range matched {
+		if !isSupersededRule(r, matched) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// isSupersededRule checks if any of the matched rules supersedes the rule.
+func isSupersededRule(r Rule, matched []Rule) bool {
+	for _, v := range matched {
+		if v.supersedes(r.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRuleSeverity returns the severity of the rule for the statement.
+// The statement with Condition is lowered by one level, because the condition restricts when the permissions are granted.
+func getRuleSeverity(r Rule, s Statement) Severity {
+	if s.HasCondition() && r.Severity > SeverityLow {
+		return r.Severity - 1
+	}
+	return r.Severity
+}
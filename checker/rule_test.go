@@ -0,0 +1,140 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+)
+
+// newTestPolicy returns the managed policy of the policy document.
+func newTestPolicy(t *testing.T, name, doc string) *AwsPolicy {
+	t.Helper()
+	pd, err := NewPolicyDocumentFromJSONString(doc)
+	if err != nil {
+		t.Fatalf("invalid policy document: %v", err)
+	}
+	return &AwsPolicy{
+		ARN:        "arn:aws:iam::012345678901:policy/" + name,
+		PolicyName: name,
+		Policy:     pd,
+	}
+}
+
+// formatFindings returns `<rule>:<severity>` of the findings.
+func formatFindings(list []Finding) string {
+	result := make([]string, len(list))
+	for i, v := range list {
+		result[i] = v.Rule.Name + ":" + v.Severity.String()
+	}
+	return strings.Join(result, " ")
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "admin is reported once",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`,
+			want: "full-admin:critical",
+		},
+		{
+			name: "iam admin supersedes passrole",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:*","Resource":"*"}]}`,
+			want: "iam-admin:high write-all-resources:medium",
+		},
+		{
+			name: "passrole only",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:PassRole","Resource":"*"}]}`,
+			want: "passrole-all:high",
+		},
+		{
+			name: "passrole on a role",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"iam:PassRole","Resource":"arn:aws:iam::012345678901:role/app"}]}`,
+			want: "",
+		},
+		{
+			name: "rules are applied per statement",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":"*","Resource":"*"},
+				{"Effect":"Allow","Action":"iam:PassRole","Resource":"*"}
+			]}`,
+			want: "full-admin:critical passrole-all:high",
+		},
+		{
+			name: "iam admin denied",
+			doc: `{"Statement":[
+				{"Effect":"Allow","Action":["iam:*","s3:GetObject"],"Resource":"*"},
+				{"Effect":"Deny","Action":"iam:*","Resource":"*"}
+			]}`,
+			want: "",
+		},
+		{
+			name: "NotAction",
+			doc:  `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`,
+			want: "assumerole-all:high write-all-resources:medium allow-not-action:medium",
+		},
+		{
+			name: "read actions",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:ListBucket"],"Resource":"*"}]}`,
+			want: "",
+		},
+		{
+			name: "conditional statement is lowered",
+			doc:  `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}]}`,
+			want: "full-admin:high",
+		},
+		{
+			name: "conditional NotAction",
+			doc:  `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"arn:aws:s3:::a","Condition":{"IpAddress":{"aws:SourceIp":"10.0.0.0/8"}}}]}`,
+			want: "allow-not-action:low",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := checkRules(DefaultRules(), []*AwsPolicy{newTestPolicy(t, "test", tt.doc)})
+			if got := formatFindings(findings); got != tt.want {
+				t.Errorf("checkRules() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRulesConditional(t *testing.T) {
+	doc := `{"Statement":[
+		{"Effect":"Allow","Action":"iam:PassRole","Resource":"*"},
+		{"Effect":"Allow","Action":"sts:AssumeRole","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}}
+	]}`
+	findings := checkRules(DefaultRules(), []*AwsPolicy{newTestPolicy(t, "test", doc)})
+	if len(findings) != 2 {
+		t.Fatalf("checkRules() = %q, want 2 findings", formatFindings(findings))
+	}
+	if f := findings[0]; f.Conditional || f.Severity != SeverityHigh || f.StatementIndex != 0 {
+		t.Errorf("unconditional finding = {conditional:%v severity:%s index:%d}", f.Conditional, f.Severity, f.StatementIndex)
+	}
+	if f := findings[1]; !f.Conditional || f.Severity != SeverityMedium || f.Rule.Severity != SeverityHigh || f.StatementIndex != 1 {
+		t.Errorf("conditional finding = {conditional:%v severity:%s rule_severity:%s index:%d}", f.Conditional, f.Severity, f.Rule.Severity, f.StatementIndex)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Severity
+		wantErr bool
+	}{
+		{"critical", SeverityCritical, false},
+		{"High", SeverityHigh, false},
+		{"MEDIUM", SeverityMedium, false},
+		{"low", SeverityLow, false},
+		{"unknown", SeverityUnknown, true},
+		{"", SeverityUnknown, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSeverity(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSeverity(%q) = (%s, %v), want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package checker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	SDK "github.com/aws/aws-sdk-go/service/iam"
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// AuthorizationDetails contains the result of `aws iam get-account-authorization-details`.
+type AuthorizationDetails struct {
+	UserDetailList  []UserDetail    `json:"UserDetailList"`
+	GroupDetailList []GroupDetail   `json:"GroupDetailList"`
+	RoleDetailList  []RoleDetail    `json:"RoleDetailList"`
+	Policies        []ManagedPolicy `json:"Policies"`
+}
+
+// UserDetail contains user data in AuthorizationDetails.
+type UserDetail struct {
+	UserName                string           `json:"UserName"`
+	UserID                  string           `json:"UserId"`
+	Arn                     string           `json:"Arn"`
+	Path                    string           `json:"Path"`
+	GroupList               []string         `json:"GroupList"`
+	UserPolicyList          []InlinePolicy   `json:"UserPolicyList"`
+	AttachedManagedPolicies []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+// GroupDetail contains group data in AuthorizationDetails.
+type GroupDetail struct {
+	GroupName               string           `json:"GroupName"`
+	GroupID                 string           `json:"GroupId"`
+	Arn                     string           `json:"Arn"`
+	Path                    string           `json:"Path"`
+	GroupPolicyList         []InlinePolicy   `json:"GroupPolicyList"`
+	AttachedManagedPolicies []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+// RoleDetail contains role data in AuthorizationDetails.
+type RoleDetail struct {
+	RoleName                 string           `json:"RoleName"`
+	RoleID                   string           `json:"RoleId"`
+	Arn                      string           `json:"Arn"`
+	Path                     string           `json:"Path"`
+	AssumeRolePolicyDocument json.RawMessage  `json:"AssumeRolePolicyDocument"`
+	RolePolicyList           []InlinePolicy   `json:"RolePolicyList"`
+	AttachedManagedPolicies  []AttachedPolicy `json:"AttachedManagedPolicies"`
+}
+
+// ManagedPolicy contains managed policy data in AuthorizationDetails.
+type ManagedPolicy struct {
+	PolicyName        string          `json:"PolicyName"`
+	PolicyID          string          `json:"PolicyId"`
+	Arn               string          `json:"Arn"`
+	Path              string          `json:"Path"`
+	DefaultVersionID  string          `json:"DefaultVersionId"`
+	AttachmentCount   int64           `json:"AttachmentCount"`
+	PolicyVersionList []PolicyVersion `json:"PolicyVersionList"`
+}
+
+// PolicyVersion contains a version of managed policy.
+type PolicyVersion struct {
+	VersionID        string          `json:"VersionId"`
+	IsDefaultVersion bool            `json:"IsDefaultVersion"`
+	Document         json.RawMessage `json:"Document"`
+}
+
+// InlinePolicy contains inline policy of user/group/role.
+type InlinePolicy struct {
+	PolicyName     string          `json:"PolicyName"`
+	PolicyDocument json.RawMessage `json:"PolicyDocument"`
+}
+
+// AttachedPolicy contains attached managed policy name and ARN.
+type AttachedPolicy struct {
+	PolicyName string `json:"PolicyName"`
+	PolicyArn  string `json:"PolicyArn"`
+}
+
+// SnapshotClient reads IAM data from AuthorizationDetails instead of AWS API.
+type SnapshotClient struct {
+	details AuthorizationDetails
+
+	users    map[string]UserDetail
+	groups   map[string]GroupDetail
+	roles    map[string]RoleDetail
+	policies map[string]ManagedPolicy
+}
+
+// NewSnapshotClient loads the JSON file of `aws iam get-account-authorization-details`.
+func NewSnapshotClient(file string) (*SnapshotClient, error) {
+	byt, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	d := AuthorizationDetails{}
+	if err := json.Unmarshal(byt, &d); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as authorization details: %s", file, err.Error())
+	}
+	return NewSnapshotClientFromDetails(d), nil
+}
+
+// NewSnapshotClientFromDetails creates *SnapshotClient from AuthorizationDetails.
+func NewSnapshotClientFromDetails(d AuthorizationDetails) *SnapshotClient {
+	c := &SnapshotClient{
+		details:  d,
+		users:    make(map[string]UserDetail, len(d.UserDetailList)),
+		groups:   make(map[string]GroupDetail, len(d.GroupDetailList)),
+		roles:    make(map[string]RoleDetail, len(d.RoleDetailList)),
+		policies: make(map[string]ManagedPolicy, len(d.Policies)),
+	}
+	for _, u := range d.UserDetailList {
+		c.users[u.UserName] = u
+	}
+	for _, g := range d.GroupDetailList {
+		c.groups[g.GroupName] = g
+	}
+	for _, r := range d.RoleDetailList {
+		c.roles[r.RoleName] = r
+	}
+	for _, p := range d.Policies {
+		c.policies[p.Arn] = p
+	}
+	return c
+}
+
+// ListAttachedPolicies returns managed policies attached to any entity.
+func (c *SnapshotClient) ListAttachedPolicies() ([]iam.Policy, error) {
+	return c.ListPolicies(PolicyScopeAll, true)
+}
+
+// ListPolicies returns managed policies in the scope.
+// scope is one of `aws`, `local` or `all`.
+func (c *SnapshotClient) ListPolicies(scope string, onlyAttached bool) ([]iam.Policy, error) {
+	list := make([]iam.Policy, 0, len(c.details.Policies))
+	for _, p := range c.details.Policies {
+		if onlyAttached && p.AttachmentCount == 0 {
+			continue
+		}
+		if !isPolicyInScope(p.Arn, scope) {
+			continue
+		}
+		list = append(list, iam.Policy{
+			ARN:             p.Arn,
+			PolicyID:        p.PolicyID,
+			PolicyName:      p.PolicyName,
+			VersionID:       p.DefaultVersionID,
+			AttachmentCount: p.AttachmentCount,
+		})
+	}
+	return list, nil
+}
+
+// GetPolicyVersion returns the version of managed policy.
+// Document is URL-encoded as same as the API response.
+func (c *SnapshotClient) GetPolicyVersion(arn, versionID string) (*SDK.PolicyVersion, error) {
+	p, ok := c.policies[arn]
+	if !ok {
+		return nil, fmt.Errorf("policy is not found in snapshot: arn=[%s]", arn)
+	}
+
+	for _, v := range p.PolicyVersionList {
+		if v.VersionID != versionID {
+			continue
+		}
+		doc, err := documentJSON(v.Document)
+		if err != nil {
+			return nil, err
+		}
+		return &SDK.PolicyVersion{
+			VersionId:        aws.String(v.VersionID),
+			IsDefaultVersion: aws.Bool(v.IsDefaultVersion),
+			Document:         aws.String(url.QueryEscape(doc)),
+		}, nil
+	}
+	return nil, fmt.Errorf("policy version is not found in snapshot: arn=[%s] version=[%s]", arn, versionID)
+}
+
+// ListPolicyVersions returns all of the versions of managed policy.
+// Document is not contained in the versions as same as the API response.
+func (c *SnapshotClient) ListPolicyVersions(arn string) ([]*SDK.PolicyVersion, error) {
+	p, ok := c.policies[arn]
+	if !ok {
+		return nil, fmt.Errorf("policy is not found in snapshot: arn=[%s]", arn)
+	}
+
+	list := make([]*SDK.PolicyVersion, len(p.PolicyVersionList))
+	for i, v := range p.PolicyVersionList {
+		list[i] = &SDK.PolicyVersion{
+			VersionId:        aws.String(v.VersionID),
+			IsDefaultVersion: aws.Bool(v.IsDefaultVersion),
+		}
+	}
+	return list, nil
+}
+
+// ListEntitiesForPolicy returns users, groups and roles attached the managed policy.
+func (c *SnapshotClient) ListEntitiesForPolicy(arn string) ([]iam.PolicyEntity, error) {
+	var list []iam.PolicyEntity
+	for _, u := range c.details.UserDetailList {
+		if hasAttachedPolicy(u.AttachedManagedPolicies, arn) {
+			list = append(list, iam.PolicyEntity{Type: iam.NewEntityTypeUser(), ID: u.UserID, Name: u.UserName})
+		}
+	}
+	for _, g := range c.details.GroupDetailList {
+		if hasAttachedPolicy(g.AttachedManagedPolicies, arn) {
+			list = append(list, iam.PolicyEntity{Type: iam.NewEntityTypeGroup(), ID: g.GroupID, Name: g.GroupName})
+		}
+	}
+	for _, r := range c.details.RoleDetailList {
+		if hasAttachedPolicy(r.AttachedManagedPolicies, arn) {
+			list = append(list, iam.PolicyEntity{Type: iam.NewEntityTypeRole(), ID: r.RoleID, Name: r.RoleName})
+		}
+	}
+	return list, nil
+}
+
+// GetGroup returns the group and its member users.
+func (c *SnapshotClient) GetGroup(groupName string) (*SDK.GetGroupOutput, error) {
+	g, ok := c.groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("group is not found in snapshot: group=[%s]", groupName)
+	}
+
+	o := &SDK.GetGroupOutput{
+		Group: &SDK.Group{
+			GroupName: aws.String(g.GroupName),
+			GroupId:   aws.String(g.GroupID),
+			Arn:       aws.String(g.Arn),
+			Path:      aws.String(g.Path),
+		},
+	}
+	for _, u := range c.details.UserDetailList {
+		for _, name := range u.GroupList {
+			if name != groupName {
+				continue
+			}
+			o.Users = append(o.Users, &SDK.User{
+				UserName: aws.String(u.UserName),
+				UserId:   aws.String(u.UserID),
+				Arn:      aws.String(u.Arn),
+				Path:     aws.String(u.Path),
+			})
+			break
+		}
+	}
+	return o, nil
+}
+
+// ListUsers returns all of the users.
+func (c *SnapshotClient) ListUsers() ([]iam.User, error) {
+	list := make([]iam.User, len(c.details.UserDetailList))
+	for i, u := range c.details.UserDetailList {
+		list[i] = iam.User{
+			ARN:      u.Arn,
+			UserID:   u.UserID,
+			UserName: u.UserName,
+			Path:     u.Path,
+		}
+	}
+	return list, nil
+}
+
+// ListUserPolicies returns inline policy names of the user.
+func (c *SnapshotClient) ListUserPolicies(userName string) ([]string, error) {
+	u, ok := c.users[userName]
+	if !ok {
+		return nil, fmt.Errorf("user is not found in snapshot: user=[%s]", userName)
+	}
+	return getInlinePolicyNames(u.UserPolicyList), nil
+}
+
+// GetUserPolicyDocument returns inline policy document of the user.
+func (c *SnapshotClient) GetUserPolicyDocument(userName, policyName string) (*PolicyDocument, error) {
+	u, ok := c.users[userName]
+	if !ok {
+		return nil, fmt.Errorf("user is not found in snapshot: user=[%s]", userName)
+	}
+	return getInlinePolicyDocument(u.UserPolicyList, policyName)
+}
+
+// ListGroups returns all of the groups.
+func (c *SnapshotClient) ListGroups() ([]iam.Group, error) {
+	list := make([]iam.Group, len(c.details.GroupDetailList))
+	for i, g := range c.details.GroupDetailList {
+		list[i] = iam.Group{
+			ARN:       g.Arn,
+			GroupID:   g.GroupID,
+			GroupName: g.GroupName,
+			Path:      g.Path,
+		}
+	}
+	return list, nil
+}
+
+// ListGroupPolicies returns inline policy names of the group.
+func (c *SnapshotClient) ListGroupPolicies(groupName string) ([]string, error) {
+	g, ok := c.groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("group is not found in snapshot: group=[%s]", groupName)
+	}
+	return getInlinePolicyNames(g.GroupPolicyList), nil
+}
+
+// GetGroupPolicyDocument returns inline policy document of the group.
+func (c *SnapshotClient) GetGroupPolicyDocument(groupName, policyName string) (*PolicyDocument, error) {
+	g, ok := c.groups[groupName]
+	if !ok {
+		return nil, fmt.Errorf("group is not found in snapshot: group=[%s]", groupName)
+	}
+	return getInlinePolicyDocument(g.GroupPolicyList, policyName)
+}
+
+// ListRoles returns all of the roles.
+func (c *SnapshotClient) ListRoles() ([]iam.Role, error) {
+	list := make([]iam.Role, len(c.details.RoleDetailList))
+	for i, r := range c.details.RoleDetailList {
+		list[i] = iam.Role{
+			ARN:      r.Arn,
+			RoleID:   r.RoleID,
+			RoleName: r.RoleName,
+			Path:     r.Path,
+		}
+		if len(r.AssumeRolePolicyDocument) != 0 {
+			doc, err := documentJSON(r.AssumeRolePolicyDocument)
+			if err != nil {
+				return nil, fmt.Errorf("invalid AssumeRolePolicyDocument: role=[%s] error=[%s]", r.RoleName, err.Error())
+			}
+			list[i].AssumeRolePolicyDocument = doc
+		}
+	}
+	return list, nil
+}
+
+// ListRolePolicies returns inline policy names of the role.
+func (c *SnapshotClient) ListRolePolicies(roleName string) ([]string, error) {
+	r, ok := c.roles[roleName]
+	if !ok {
+		return nil, fmt.Errorf("role is not found in snapshot: role=[%s]", roleName)
+	}
+	return getInlinePolicyNames(r.RolePolicyList), nil
+}
+
+// GetRolePolicyDocument returns inline policy document of the role.
+func (c *SnapshotClient) GetRolePolicyDocument(roleName, policyName string) (*PolicyDocument, error) {
+	r, ok := c.roles[roleName]
+	if !ok {
+		return nil, fmt.Errorf("role is not found in snapshot: role=[%s]", roleName)
+	}
+	return getInlinePolicyDocument(r.RolePolicyList, policyName)
+}
+
+func hasAttachedPolicy(list []AttachedPolicy, arn string) bool {
+	for _, p := range list {
+		if p.PolicyArn == arn {
+			return true
+		}
+	}
+	return false
+}
+
+func getInlinePolicyNames(list []InlinePolicy) []string {
+	result := make([]string, len(list))
+	for i, p := range list {
+		result[i] = p.PolicyName
+	}
+	return result
+}
+
+func getInlinePolicyDocument(list []InlinePolicy, policyName string) (*PolicyDocument, error) {
+	for _, p := range list {
+		if p.PolicyName != policyName {
+			continue
+		}
+		doc, err := documentJSON(p.PolicyDocument)
+		if err != nil {
+			return nil, err
+		}
+		pd, err := NewPolicyDocumentFromJSONString(doc)
+		if err != nil {
+			return nil, err
+		}
+		return &pd, nil
+	}
+	return nil, nil
+}
+
+// documentJSON returns policy document as JSON string.
+// AWS CLI outputs the document as JSON object, and AWS API returns URL-encoded JSON string.
+func documentJSON(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", errors.New("empty policy document")
+	}
+	if raw[0] != '"' {
+		return string(raw), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(strings.TrimSpace(s), "{") {
+		return s, nil
+	}
+	return url.QueryUnescape(s)
+}
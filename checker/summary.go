@@ -0,0 +1,140 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// status of the check in CheckSummary.
+const (
+	CheckStatusOK       = "ok"
+	CheckStatusFindings = "findings"
+	CheckStatusPartial  = "partial"
+)
+
+// severities in the summary, from the highest.
+var summarySeverities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow}
+
+// CheckSummary is the summary of the check results, used to decide the exit status in CI.
+type CheckSummary struct {
+	Name       string           // check name in the config file, or check type
+	Type       string           // policy, inline_policy, audit, escalation, trust, principal or lint
+	Output     string           // output file of the results
+	Findings   int              // number of the results (issues for trust)
+	Severities map[Severity]int // number of the findings for each severity; nil for the checks without severity
+	Skipped    int              // number of the items skipped by errors
+	Suppressed int              // number of the results suppressed by the suppressions
+	Expired    int              // number of the results matching only expired suppressions; they are in the findings
+	Status     string           // ok, findings or partial
+}
+
+// String returns one-line summary for CI logs.
+// (e.g. `[audit] status:findings findings:5 (critical:1 high:2 medium:2 low:0) skipped:0 suppressed:1 expired:0 output:audit.csv`)
+func (s CheckSummary) String() string {
+	msg := fmt.Sprintf("[%s] status:%s findings:%d", s.Name, s.Status, s.Findings)
+	if s.Severities != nil {
+		counts := make([]string, len(summarySeverities))
+		for i, v := range summarySeverities {
+			counts[i] = fmt.Sprintf("%s:%d", v.String(), s.Severities[v])
+		}
+		msg += " (" + strings.Join(counts, " ") + ")"
+	}
+	return msg + fmt.Sprintf(" skipped:%d suppressed:%d expired:%d output:%s", s.Skipped, s.Suppressed, s.Expired, s.Output)
+}
+
+// countAtLeast returns the number of the findings at or above the severity.
+func (s CheckSummary) countAtLeast(threshold Severity) int {
+	count := 0
+	for v, n := range s.Severities {
+		if v >= threshold {
+			count += n
+		}
+	}
+	return count
+}
+
+// FindingsError is returned when the findings exceed the threshold of FailOnMatch or FailOn.
+// The results are saved before the error is returned.
+type FindingsError struct {
+	Summaries []CheckSummary
+}
+
+func (e *FindingsError) Error() string {
+	list := make([]string, len(e.Summaries))
+	for i, s := range e.Summaries {
+		list[i] = fmt.Sprintf("%s (%d)", s.Name, s.Findings)
+	}
+	return "findings exceed the threshold: " + strings.Join(list, ", ")
+}
+
+// Summary returns the summary of the last check, or nil when no check is finished.
+func (c *PolicyChecker) Summary() *CheckSummary {
+	return c.summary
+}
+
+// finishCheck saves the suppressed results and the skipped items, and summarizes the results after the results are saved.
+// It returns *FindingsError when the findings exceed the threshold,
+// or *PartialDataError in strict mode when any item is skipped.
+// severities must be nil for the check types without severity.
+func (c *PolicyChecker) finishCheck(typ string, findings int, severities []Severity, suppressed []SuppressedItem) error {
+	if err := c.saveSuppressedItems(suppressed); err != nil {
+		return err
+	}
+	skipped, err := c.saveFetchErrors()
+	if err != nil {
+		return err
+	}
+
+	s := &CheckSummary{
+		Name:     c.getSummaryName(typ),
+		Type:     typ,
+		Output:   c.config.GetOutputFile(),
+		Findings: findings,
+		Skipped:  len(skipped),
+		Status:   CheckStatusOK,
+	}
+	if severities != nil {
+		s.Severities = make(map[Severity]int, len(summarySeverities))
+		for _, v := range severities {
+			s.Severities[v]++
+		}
+	}
+	for _, v := range suppressed {
+		if v.Expired {
+			s.Expired++
+		} else {
+			s.Suppressed++
+		}
+	}
+	c.summary = s
+
+	isFailed := c.config.FailOnMatch && s.Findings != 0
+	if threshold := c.config.GetFailOnSeverity(); threshold != SeverityUnknown && s.countAtLeast(threshold) != 0 {
+		isFailed = true
+	}
+	switch {
+	case isFailed:
+		s.Status = CheckStatusFindings
+		return &FindingsError{Summaries: []CheckSummary{*s}}
+	case c.config.Strict && len(skipped) != 0:
+		s.Status = CheckStatusPartial
+		return &PartialDataError{Errors: skipped}
+	}
+	return nil
+}
+
+func (c *PolicyChecker) getSummaryName(typ string) string {
+	if c.checkName != "" {
+		return c.checkName
+	}
+	return typ
+}
+
+// hasSeverity checks if the results of the check type have severity.
+func hasSeverity(typ string) bool {
+	switch typ {
+	case CheckTypeAudit, CheckTypeTrust, CheckTypeLint:
+		return true
+	}
+	return false
+}
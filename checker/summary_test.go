@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSummaryChecker returns *PolicyChecker which has the skipped items by errors.
+func newSummaryChecker(t *testing.T, conf Config, skipped int) *PolicyChecker {
+	t.Helper()
+	logger, err := NewStdLogger(&strings.Builder{}, "error", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.OutputFile = filepath.Join(t.TempDir(), "audit.csv")
+	c := &PolicyChecker{config: conf, logger: logger}
+	for i := 0; i < skipped; i++ {
+		c.addFetchError(FetchError{Func: "GetPolicyVersion", Error: "AccessDenied"})
+	}
+	return c
+}
+
+func TestFinishCheck(t *testing.T) {
+	severities := []Severity{SeverityHigh, SeverityMedium, SeverityMedium}
+	tests := []struct {
+		name       string
+		conf       Config
+		severities []Severity
+		skipped    int
+		wantStatus string
+	}{
+		{"no threshold", Config{}, severities, 0, CheckStatusOK},
+		{"fail on match", Config{FailOnMatch: true}, severities, 0, CheckStatusFindings},
+		{"fail on match without findings", Config{FailOnMatch: true}, []Severity{}, 0, CheckStatusOK},
+		{"fail on severity", Config{FailOn: "high"}, severities, 0, CheckStatusFindings},
+		{"fail on higher severity", Config{FailOn: "critical"}, severities, 0, CheckStatusOK},
+		{"fail on case-insensitive severity", Config{FailOn: "MEDIUM"}, severities, 0, CheckStatusFindings},
+		{"skipped items", Config{}, severities, 2, CheckStatusOK},
+		{"skipped items in strict mode", Config{Strict: true}, severities, 2, CheckStatusPartial},
+		{"strict mode without skipped items", Config{Strict: true}, severities, 0, CheckStatusOK},
+		{"findings are prior to skipped items", Config{Strict: true, FailOn: "medium"}, severities, 2, CheckStatusFindings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newSummaryChecker(t, tt.conf, tt.skipped)
+			err := c.finishCheck(CheckTypeAudit, len(tt.severities), tt.severities, nil)
+
+			var findingsErr *FindingsError
+			var partialErr *PartialDataError
+			switch tt.wantStatus {
+			case CheckStatusOK:
+				if err != nil {
+					t.Errorf("finishCheck() error = %v", err)
+				}
+			case CheckStatusFindings:
+				if !errors.As(err, &findingsErr) || len(findingsErr.Summaries) != 1 {
+					t.Errorf("finishCheck() error = %v, want *FindingsError", err)
+				}
+			case CheckStatusPartial:
+				if !errors.As(err, &partialErr) || len(partialErr.Errors) != tt.skipped {
+					t.Errorf("finishCheck() error = %v, want *PartialDataError", err)
+				}
+			}
+
+			s := c.Summary()
+			if s.Status != tt.wantStatus || s.Findings != len(tt.severities) || s.Skipped != tt.skipped {
+				t.Errorf("Summary() = %s, want status:%s", s.String(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestFinishCheckWithoutSeverity(t *testing.T) {
+	// fail_on is not used for the check types without severity.
+	c := newSummaryChecker(t, Config{FailOn: "low"}, 0)
+	if err := c.finishCheck(CheckTypePolicy, 3, nil, nil); err != nil {
+		t.Errorf("finishCheck() error = %v", err)
+	}
+	if s := c.Summary(); s.Severities != nil || s.Status != CheckStatusOK {
+		t.Errorf("Summary() = %s", s.String())
+	}
+}
+
+func TestCheckSummaryString(t *testing.T) {
+	s := CheckSummary{
+		Name:       "audit",
+		Findings:   5,
+		Severities: map[Severity]int{SeverityCritical: 1, SeverityHigh: 2, SeverityMedium: 2},
+		Suppressed: 1,
+		Output:     "audit.csv",
+		Status:     CheckStatusFindings,
+	}
+	want := "[audit] status:findings findings:5 (critical:1 high:2 medium:2 low:0) skipped:0 suppressed:1 expired:0 output:audit.csv"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	s = CheckSummary{Name: "policy", Findings: 2, Skipped: 1, Output: "policy.csv", Status: CheckStatusPartial}
+	want = "[policy] status:partial findings:2 skipped:1 suppressed:0 expired:0 output:policy.csv"
+	if got := s.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestFindingsErrorMessage(t *testing.T) {
+	err := &FindingsError{Summaries: []CheckSummary{{Name: "audit", Findings: 3}, {Name: "trust", Findings: 1}}}
+	if got, want := err.Error(), "findings exceed the threshold: audit (3), trust (1)"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestCheckFileRunWithClientExitErrors(t *testing.T) {
+	snap, err := NewSnapshotClient(testSnapshotFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &failingClient{IAMClient: snap, policyARN: "arn:aws:iam::012345678901:policy/AuditAccess"}
+
+	tests := []struct {
+		name         string
+		strict       bool
+		checks       []Check
+		wantFindings string
+		wantSkipped  int
+	}{
+		{
+			name:   "no threshold",
+			checks: []Check{{Name: "policy", All: true}, {Name: "audit", Type: CheckTypeAudit}},
+		},
+		{
+			name:         "findings of the checks",
+			checks:       []Check{{Name: "policy", All: true, FailOnMatch: true}, {Name: "audit", Type: CheckTypeAudit, FailOn: "low"}},
+			wantFindings: "policy audit",
+		},
+		{
+			name:        "skipped items in strict mode",
+			strict:      true,
+			checks:      []Check{{Name: "policy", All: true}, {Name: "principal", Type: CheckTypePrincipal}},
+			wantSkipped: 2,
+		},
+		{
+			name:         "findings are prior to skipped items",
+			strict:       true,
+			checks:       []Check{{Name: "policy", All: true}, {Name: "audit", Type: CheckTypeAudit, FailOn: "low"}},
+			wantFindings: "audit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			f := CheckFile{LogLevel: "error", Strict: tt.strict, Checks: tt.checks}
+			for i := range f.Checks {
+				f.Checks[i].Output = filepath.Join(dir, f.Checks[i].Name+".csv")
+			}
+
+			summaries, err := f.RunWithClient(cli)
+			if len(summaries) != len(tt.checks) {
+				t.Errorf("RunWithClient() = %d summaries, want %d", len(summaries), len(tt.checks))
+			}
+
+			var findingsErr *FindingsError
+			var partialErr *PartialDataError
+			switch {
+			case tt.wantFindings != "":
+				if !errors.As(err, &findingsErr) {
+					t.Fatalf("RunWithClient() error = %v, want *FindingsError", err)
+				}
+				names := make([]string, len(findingsErr.Summaries))
+				for i, s := range findingsErr.Summaries {
+					names[i] = s.Name
+				}
+				if got := strings.Join(names, " "); got != tt.wantFindings {
+					t.Errorf("FindingsError.Summaries = %q, want %q", got, tt.wantFindings)
+				}
+			case tt.wantSkipped != 0:
+				if !errors.As(err, &partialErr) || len(partialErr.Errors) != tt.wantSkipped {
+					t.Errorf("RunWithClient() error = %v, want *PartialDataError with %d errors", err, tt.wantSkipped)
+				}
+			case err != nil:
+				t.Errorf("RunWithClient() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const suppressionDateLayout = "2006-01-02"
+
+// SuppressionFile is YAML file of accepted exceptions.
+//
+//	suppressions:
+//	  - policy: arn:aws:iam::123456789012:policy/BreakGlassAdmin
+//	    entity: role/break-glass
+//	    rule: full-admin
+//	    justification: emergency access reviewed by the security team
+//	    owner: security@example.com
+//	    expires: 2021-12-31
+type SuppressionFile struct {
+	Suppressions []Suppression `yaml:"suppressions"`
+}
+
+// Suppression is an accepted exception of the results.
+// A result is suppressed when it matches all of the given fields, until the end of the expiry date (UTC).
+// Wildcard `*` and `?` can be used in the fields, and they are case-insensitive.
+type Suppression struct {
+	Policy        string `yaml:"policy" json:"policy,omitempty"` // policy name or ARN
+	Entity        string `yaml:"entity" json:"entity,omitempty"` // `<type>/<name>` of User/Group/Role (e.g. `role/break-glass`)
+	Action        string `yaml:"action" json:"action,omitempty"` // action in the result (e.g. `iam:*`)
+	Rule          string `yaml:"rule" json:"rule,omitempty"`     // rule of audit and trust, issue of lint, or path of escalation
+	Justification string `yaml:"justification" json:"justification"`
+	Owner         string `yaml:"owner" json:"owner"`
+	Expires       string `yaml:"expires" json:"expires"` // YYYY-MM-DD
+}
+
+// ReadSuppressionFile reads and validates YAML file of the suppressions.
+func ReadSuppressionFile(file string) ([]Suppression, error) {
+	byt, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	f := SuppressionFile{}
+	if err := yaml.UnmarshalStrict(byt, &f); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' as suppression file: %s", file, err.Error())
+	}
+	for i, s := range f.Suppressions {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("suppression #%d in '%s': %s", i+1, file, err.Error())
+		}
+	}
+	return f.Suppressions, nil
+}
+
+// Validate validates the suppression has any of the matching fields, the justification, owner and expiry date.
+func (s Suppression) Validate() error {
+	if s.Policy == "" && s.Entity == "" && s.Action == "" && s.Rule == "" {
+		return errors.New("any of policy, entity, action and rule is required")
+	}
+	switch {
+	case s.Justification == "":
+		return errors.New("justification is empty")
+	case s.Owner == "":
+		return errors.New("owner is empty")
+	case s.Expires == "":
+		return errors.New("expires is empty")
+	}
+	if _, err := time.Parse(suppressionDateLayout, s.Expires); err != nil {
+		return fmt.Errorf("expires must be YYYY-MM-DD: '%s'", s.Expires)
+	}
+	return nil
+}
+
+// IsExpired checks if the expiry date has passed.
+func (s Suppression) IsExpired(now time.Time) bool {
+	t, err := time.Parse(suppressionDateLayout, s.Expires)
+	if err != nil {
+		return true
+	}
+	return !now.UTC().Before(t.AddDate(0, 0, 1))
+}
+
+// match checks if all of the values of the target match the fields.
+func (s Suppression) match(t suppressionTarget) bool {
+	if s.Policy != "" {
+		if len(t.policies) == 0 {
+			return false
+		}
+		for _, p := range t.policies {
+			if !matchSuppressionPattern(s.Policy, p.PolicyName, p.ARN) {
+				return false
+			}
+		}
+	}
+	if s.Entity != "" && !matchAllSuppressionPattern(s.Entity, t.entities) {
+		return false
+	}
+	if s.Action != "" && !matchAllSuppressionPattern(s.Action, t.actions) {
+		return false
+	}
+	if s.Rule != "" && !matchSuppressionPattern(s.Rule, t.rule) {
+		return false
+	}
+	return true
+}
+
+// matchSuppressionPattern checks if any of the values matches the pattern.
+// Unlike the exclusion rules, the pattern without wildcard must match the whole value.
+func matchSuppressionPattern(pattern string, values ...string) bool {
+	pattern = strings.ToLower(pattern)
+	for _, v := range values {
+		if v != "" && matchWildcard(pattern, strings.ToLower(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAllSuppressionPattern checks if all of the values match the pattern.
+// A policy attached to other entities is not suppressed by the entity.
+func matchAllSuppressionPattern(pattern string, values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if !matchSuppressionPattern(pattern, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// SuppressedItem is a result suppressed by the suppression.
+// The result of expired suppression is not suppressed and it's still in the results.
+type SuppressedItem struct {
+	Item        string      `json:"item"` // description of the result (e.g. `full-admin: AdminPolicy #0`)
+	Suppression Suppression `json:"suppression"`
+	Expired     bool        `json:"expired"`
+	Result      interface{} `json:"result"`
+}
+
+// suppressionTarget contains the values of a result used to match the suppressions.
+type suppressionTarget struct {
+	item     string
+	policies []*AwsPolicy
+	entities []string
+	actions  []string
+	rule     string
+}
+
+func newPolicySuppressionTarget(p *AwsPolicy) suppressionTarget {
+	return suppressionTarget{
+		item:     getPolicyLabel(p),
+		policies: []*AwsPolicy{p},
+		entities: p.GetEntities(),
+		actions:  p.PolicyActions,
+	}
+}
+
+// getPolicyLabel returns the policy name with ARN or the entity of inline policy. (e.g. `AdminPolicy (role/admin)`)
+func getPolicyLabel(p *AwsPolicy) string {
+	if p.ARN != "" {
+		return p.PolicyName + " (" + p.ARN + ")"
+	}
+	return p.PolicyName + " (" + strings.Join(p.GetEntities(), ", ") + ")"
+}
+
+// suppress returns the suppression in the list which matches the target.
+// The valid suppression is used first, and the expired suppression is returned when no valid one matches.
+func (c *PolicyChecker) suppress(list []Suppression, t suppressionTarget, result interface{}) (item SuppressedItem, ok bool) {
+	now := time.Now()
+	for _, s := range list {
+		if !s.match(t) {
+			continue
+		}
+		item = SuppressedItem{
+			Item:        t.item,
+			Suppression: s,
+			Expired:     s.IsExpired(now),
+			Result:      result,
+		}
+		ok = true
+		if !item.Expired {
+			return item, true
+		}
+	}
+	if ok {
+		c.loggingWarn("Func:[suppress] Warning:[suppression is expired], Item:[%s], Owner:[%s], Expires:[%s]", item.Item, item.Suppression.Owner, item.Suppression.Expires)
+	}
+	return item, ok
+}
+
+// suppressPolicies removes the suppressed managed and inline policies.
+func (c *PolicyChecker) suppressPolicies(list []*AwsPolicy) ([]*AwsPolicy, []SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return list, nil, err
+	}
+
+	result := make([]*AwsPolicy, 0, len(list))
+	var suppressed []SuppressedItem
+	for _, p := range list {
+		item, ok := c.suppress(suppressions, newPolicySuppressionTarget(p), p)
+		if ok {
+			suppressed = append(suppressed, item)
+		}
+		if !ok || item.Expired {
+			result = append(result, p)
+		}
+	}
+	return result, suppressed, nil
+}
+
+// suppressFindings removes the suppressed findings of audit.
+func (c *PolicyChecker) suppressFindings(list []Finding) ([]Finding, []SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return list, nil, err
+	}
+
+	result := make([]Finding, 0, len(list))
+	var suppressed []SuppressedItem
+	for _, f := range list {
+		t := newPolicySuppressionTarget(f.Policy)
+		t.item = f.Rule.Name + ": " + t.item + " #" + strconv.Itoa(f.StatementIndex)
+		t.actions = f.Statement.Action
+		t.rule = f.Rule.Name
+
+		item, ok := c.suppress(suppressions, t, f)
+		if ok {
+			suppressed = append(suppressed, item)
+		}
+		if !ok || item.Expired {
+			result = append(result, f)
+		}
+	}
+	return result, suppressed, nil
+}
+
+// suppressLintFindings removes the suppressed findings of lint.
+func (c *PolicyChecker) suppressLintFindings(list []LintFinding) ([]LintFinding, []SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return list, nil, err
+	}
+
+	result := make([]LintFinding, 0, len(list))
+	var suppressed []SuppressedItem
+	for _, f := range list {
+		t := newPolicySuppressionTarget(f.Policy)
+		t.item = f.Issue + ": " + f.Action + " in " + t.item + " #" + strconv.Itoa(f.StatementIndex)
+		t.actions = []string{f.Action}
+		t.rule = f.Issue
+
+		item, ok := c.suppress(suppressions, t, f)
+		if ok {
+			suppressed = append(suppressed, item)
+		}
+		if !ok || item.Expired {
+			result = append(result, f)
+		}
+	}
+	return result, suppressed, nil
+}
+
+// suppressEscalations removes the suppressed escalation paths.
+// The policies are all of the policies granting the permissions of the path.
+func (c *PolicyChecker) suppressEscalations(list []EscalationFinding) ([]EscalationFinding, []SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return list, nil, err
+	}
+
+	result := make([]EscalationFinding, 0, len(list))
+	var suppressed []SuppressedItem
+	for _, f := range list {
+		entity := f.Principal.Type + "/" + f.Principal.Name
+		t := suppressionTarget{
+			item:     f.Path.Name + ": " + entity,
+			entities: []string{entity},
+			actions:  f.Path.Actions,
+			rule:     f.Path.Name,
+		}
+		for _, perm := range f.Permissions {
+			for _, pp := range perm.Policies {
+				t.policies = append(t.policies, pp.Policy)
+			}
+		}
+
+		item, ok := c.suppress(suppressions, t, f)
+		if ok {
+			suppressed = append(suppressed, item)
+		}
+		if !ok || item.Expired {
+			result = append(result, f)
+		}
+	}
+	return result, suppressed, nil
+}
+
+// suppressTrustIssues removes the suppressed issues from the roles.
+// The roles are still in the results, since the results contain all of the roles.
+func (c *PolicyChecker) suppressTrustIssues(list []*RoleTrust) ([]SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return nil, err
+	}
+
+	var suppressed []SuppressedItem
+	for _, r := range list {
+		entity := entityRole + "/" + r.RoleName
+		issues := make([]TrustIssue, 0, len(r.Issues))
+		for _, v := range r.Issues {
+			t := suppressionTarget{
+				item:     v.Rule.Name + ": " + entity + " (" + v.Principal.String() + ")",
+				entities: []string{entity},
+				actions:  v.Principal.Actions,
+				rule:     v.Rule.Name,
+			}
+
+			item, ok := c.suppress(suppressions, t, v)
+			if ok {
+				suppressed = append(suppressed, item)
+			}
+			if !ok || item.Expired {
+				issues = append(issues, v)
+			}
+		}
+		r.Issues = issues
+	}
+	return suppressed, nil
+}
+
+// suppressPrincipals removes the suppressed users and roles.
+// The policies are all of the policies granted to the principal.
+func (c *PolicyChecker) suppressPrincipals(list []*Principal) ([]*Principal, []SuppressedItem, error) {
+	suppressions, err := c.config.GetSuppressions()
+	if err != nil || len(suppressions) == 0 {
+		return list, nil, err
+	}
+
+	result := make([]*Principal, 0, len(list))
+	var suppressed []SuppressedItem
+	for _, p := range list {
+		entity := p.Type + "/" + p.Name
+		t := suppressionTarget{
+			item:     entity,
+			entities: []string{entity},
+			actions:  getPrincipalActions(p),
+		}
+		for _, pp := range p.Policies {
+			t.policies = append(t.policies, pp.Policy)
+		}
+
+		item, ok := c.suppress(suppressions, t, p)
+		if ok {
+			suppressed = append(suppressed, item)
+		}
+		if !ok || item.Expired {
+			result = append(result, p)
+		}
+	}
+	return result, suppressed, nil
+}
+
+// getPrincipalActions returns the actions in Allow statements of the principal.
+// NotAction is handled as `*`, since it grants all of the other actions.
+func getPrincipalActions(p *Principal) []string {
+	var result []string
+	for _, s := range p.GetStatements() {
+		if !s.IsAllow() {
+			continue
+		}
+		result = append(result, s.Action...)
+		if len(s.NotAction) != 0 {
+			result = append(result, "*")
+		}
+	}
+	return result
+}
+
+// SaveSuppressedItems saves the suppressed results to the suppressed output file.
+func (c *PolicyChecker) SaveSuppressedItems(list []SuppressedItem) error {
+	c.loggingInfo("invoking `SaveSuppressedItems` size:[%d] ...", len(list))
+
+	f, err := NewFileHandlerWithFormat(c.config.GetSuppressedOutputFile(), c.config.OutputFormat)
+	if err != nil {
+		return err
+	}
+	if f.IsJSON() {
+		return f.WriteObjects(toObjects(list))
+	}
+
+	// CSV headers
+	headers := []string{
+		"item",
+		"expired",
+		"policy",
+		"entity",
+		"action",
+		"rule",
+		"justification",
+		"owner",
+		"expires",
+	}
+
+	lines := make([][]string, 0, len(list))
+	for _, v := range list {
+		s := v.Suppression
+		lines = append(lines, []string{
+			v.Item,
+			strconv.FormatBool(v.Expired),
+			s.Policy,
+			s.Entity,
+			s.Action,
+			s.Rule,
+			s.Justification,
+			s.Owner,
+			s.Expires,
+		})
+	}
+	return f.WriteAll(headers, lines)
+}
+
+// saveSuppressedItems saves the suppressed results when the suppression file is set.
+func (c *PolicyChecker) saveSuppressedItems(list []SuppressedItem) error {
+	if !c.config.hasSuppression() {
+		return nil
+	}
+	return c.SaveSuppressedItems(list)
+}
+
+// toSuppressedOutputFile returns the suppressed output file next to the output file. (e.g. `policy.csv` to `policy.suppressed.csv`)
+func toSuppressedOutputFile(file string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + ".suppressed" + ext
+}
@@ -0,0 +1,253 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSuppressionValidate(t *testing.T) {
+	valid := Suppression{Policy: "AdminPolicy", Justification: "reviewed", Owner: "security@example.com", Expires: "2021-12-31"}
+	tests := []struct {
+		name    string
+		modify  func(s *Suppression)
+		wantErr string
+	}{
+		{"valid", func(s *Suppression) {}, ""},
+		{"only rule", func(s *Suppression) { s.Policy, s.Rule = "", "full-admin" }, ""},
+		{"no matching field", func(s *Suppression) { s.Policy = "" }, "any of policy, entity, action and rule is required"},
+		{"no justification", func(s *Suppression) { s.Justification = "" }, "justification is empty"},
+		{"no owner", func(s *Suppression) { s.Owner = "" }, "owner is empty"},
+		{"no expires", func(s *Suppression) { s.Expires = "" }, "expires is empty"},
+		{"invalid expires", func(s *Suppression) { s.Expires = "2021/12/31" }, "expires must be YYYY-MM-DD: '2021/12/31'"},
+		{"invalid date", func(s *Suppression) { s.Expires = "2021-02-30" }, "expires must be YYYY-MM-DD: '2021-02-30'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			err := s.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Validate() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadSuppressionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", "suppressions:\n  - rule: full-admin\n    justification: reviewed\n    owner: security\n    expires: 2021-12-31\n", ""},
+		{"unknown key", "suppressions:\n  - rule: full-admin\n    justfication: reviewed\n", "cannot parse"},
+		{"invalid suppression", "suppressions:\n  - rule: full-admin\n    justification: reviewed\n    owner: security\n    expires: 2021-12-31\n  - rule: iam-admin\n    owner: security\n    expires: 2021-12-31\n", "suppression #2 in "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "suppressions.yaml")
+			if err := os.WriteFile(file, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			list, err := ReadSuppressionFile(file)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ReadSuppressionFile() error = %v", err)
+			case tt.wantErr == "" && len(list) != 1:
+				t.Errorf("ReadSuppressionFile() = %+v, want 1 suppression", list)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Errorf("ReadSuppressionFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSuppressionIsExpired(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name    string
+		expires string
+		now     time.Time
+		want    bool
+	}{
+		{"before the date", "2021-12-31", time.Date(2021, 12, 30, 12, 0, 0, 0, time.UTC), false},
+		{"start of the date", "2021-12-31", time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"end of the date", "2021-12-31", time.Date(2021, 12, 31, 23, 59, 59, 0, time.UTC), false},
+		{"next day", "2021-12-31", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"date in UTC", "2021-12-31", time.Date(2022, 1, 1, 8, 0, 0, 0, jst), false},
+		{"next day in UTC", "2021-12-31", time.Date(2022, 1, 1, 9, 0, 0, 0, jst), true},
+		{"invalid date", "2021-13-01", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Suppression{Expires: tt.expires}
+			if got := s.IsExpired(tt.now); got != tt.want {
+				t.Errorf("IsExpired(%s) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressionMatch(t *testing.T) {
+	admin := newTestPolicy(t, "AdminPolicy", `{"Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`)
+	other := newTestPolicy(t, "OtherPolicy", `{"Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`)
+	target := suppressionTarget{
+		policies: []*AwsPolicy{admin},
+		entities: []string{"role/break-glass"},
+		actions:  []string{"iam:PassRole", "iam:CreateRole"},
+		rule:     "full-admin",
+	}
+
+	tests := []struct {
+		name string
+		s    Suppression
+		t    suppressionTarget
+		want bool
+	}{
+		{"policy name", Suppression{Policy: "AdminPolicy"}, target, true},
+		{"policy ARN", Suppression{Policy: "arn:aws:iam::012345678901:policy/AdminPolicy"}, target, true},
+		{"policy pattern", Suppression{Policy: "arn:aws:iam::*:policy/admin*"}, target, true},
+		{"policy is case-insensitive", Suppression{Policy: "adminpolicy"}, target, true},
+		{"policy is not substring", Suppression{Policy: "Admin"}, target, false},
+		{"other policy", Suppression{Policy: "OtherPolicy"}, target, false},
+		{"policy of the target without policy", Suppression{Policy: "*"}, suppressionTarget{entities: []string{"role/break-glass"}}, false},
+		{"all of the policies", Suppression{Policy: "AdminPolicy"}, suppressionTarget{policies: []*AwsPolicy{admin, other}}, false},
+		{"entity", Suppression{Entity: "role/break-glass"}, target, true},
+		{"entity pattern", Suppression{Entity: "role/*"}, target, true},
+		{"entity without type", Suppression{Entity: "break-glass"}, target, false},
+		{"all of the entities", Suppression{Entity: "role/break-glass"}, suppressionTarget{entities: []string{"role/break-glass", "user/admin"}}, false},
+		{"entity of the target without entity", Suppression{Entity: "*"}, suppressionTarget{}, false},
+		{"action pattern", Suppression{Action: "iam:*"}, target, true},
+		{"all of the actions", Suppression{Action: "iam:PassRole"}, target, false},
+		{"rule", Suppression{Rule: "full-admin"}, target, true},
+		{"other rule", Suppression{Rule: "iam-admin"}, target, false},
+		{"all of the fields", Suppression{Policy: "AdminPolicy", Entity: "role/break-glass", Action: "iam:*", Rule: "full-admin"}, target, true},
+		{"one of the fields does not match", Suppression{Policy: "AdminPolicy", Entity: "role/break-glass", Rule: "iam-admin"}, target, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.match(tt.t); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuppressFindings(t *testing.T) {
+	valid := Suppression{Policy: "BreakGlass*", Rule: "full-admin", Justification: "reviewed", Owner: "security@example.com", Expires: "2999-12-31"}
+	expired := Suppression{Rule: "passrole-all", Justification: "reviewed", Owner: "security@example.com", Expires: "2000-01-01"}
+
+	c, buf := newTestChecker(t, Config{ShowAllPolicy: true})
+	c.config.suppressions = []Suppression{expired, valid}
+
+	doc := `{"Statement":[
+		{"Effect":"Allow","Action":"*","Resource":"*"},
+		{"Effect":"Allow","Action":"iam:PassRole","Resource":"*"}
+	]}`
+	findings := checkRules(DefaultRules(), []*AwsPolicy{newTestPolicy(t, "BreakGlassAdmin", doc), newTestPolicy(t, "Admin", doc)})
+	result, suppressed, err := c.suppressFindings(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the expired suppression does not remove the finding.
+	if got, want := formatFindings(result), "passrole-all:high full-admin:critical passrole-all:high"; got != want {
+		t.Errorf("suppressFindings() = %q, want %q", got, want)
+	}
+	if len(suppressed) != 3 {
+		t.Fatalf("suppressed = %d items, want 3", len(suppressed))
+	}
+	want := []struct {
+		item    string
+		expired bool
+	}{
+		{"full-admin: BreakGlassAdmin (arn:aws:iam::012345678901:policy/BreakGlassAdmin) #0", false},
+		{"passrole-all: BreakGlassAdmin (arn:aws:iam::012345678901:policy/BreakGlassAdmin) #1", true},
+		{"passrole-all: Admin (arn:aws:iam::012345678901:policy/Admin) #1", true},
+	}
+	for i, w := range want {
+		if suppressed[i].Item != w.item || suppressed[i].Expired != w.expired {
+			t.Errorf("suppressed[%d] = {item:%s expired:%v}, want {item:%s expired:%v}", i, suppressed[i].Item, suppressed[i].Expired, w.item, w.expired)
+		}
+	}
+	if n := strings.Count(buf.String(), "Warning:[suppression is expired]"); n != 2 {
+		t.Errorf("expired suppression is warned %d times, want 2: %s", n, buf.String())
+	}
+}
+
+func TestCheckPoliciesSuppression(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "suppressions.yaml")
+	data := `suppressions:
+  - policy: DeveloperAccess
+    justification: reviewed by the security team
+    owner: security@example.com
+    expires: 2999-12-31
+  - entity: role/partner-*
+    justification: temporary access of the partner
+    owner: security@example.com
+    expires: 2000-01-01
+`
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := newTestChecker(t, Config{
+		ShowAllPolicy:   true,
+		ExcludeEntity:   "user/bob",
+		OutputFile:      filepath.Join(dir, "policy.csv"),
+		SuppressionFile: file,
+	})
+	if err := c.CheckPolicies(); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, row := range readCSV(t, filepath.Join(dir, "policy.csv")) {
+		names = append(names, row["policy_name"])
+	}
+	if got, want := strings.Join(names, " "), "AuditAccess AWSSupportServiceRolePolicy"; got != want {
+		t.Errorf("policies = %q, want %q", got, want)
+	}
+
+	rows := readCSV(t, filepath.Join(dir, "policy.suppressed.csv"))
+	if len(rows) != 2 {
+		t.Fatalf("suppressed = %d rows, want 2", len(rows))
+	}
+	for _, row := range rows {
+		wantExpired := "false"
+		if strings.HasPrefix(row["item"], "AuditAccess") {
+			wantExpired = "true"
+		}
+		if row["expired"] != wantExpired {
+			t.Errorf("%s: expired = %s, want %s", row["item"], row["expired"], wantExpired)
+		}
+	}
+
+	s := c.Summary()
+	if s.Findings != 2 || s.Suppressed != 1 || s.Expired != 1 {
+		t.Errorf("Summary() = %s", s.String())
+	}
+}
+
+func TestToSuppressedOutputFile(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"policy.csv", "policy.suppressed.csv"},
+		{"out/audit.json", "out/audit.suppressed.json"},
+		{"output", "output.suppressed"},
+	}
+	for _, tt := range tests {
+		if got := toSuppressedOutputFile(tt.file); got != tt.want {
+			t.Errorf("toSuppressedOutputFile(%q) = %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evalphobia/aws-sdk-go-wrapper/iam"
+)
+
+// principal types in Principal block.
+const (
+	principalTypeAWS       = "AWS"
+	principalTypeService   = "Service"
+	principalTypeFederated = "Federated"
+)
+
+// RoleTrust contains the trust policy (AssumeRolePolicyDocument) of the role and who can assume the role.
+type RoleTrust struct {
+	RoleName   string           `json:"role_name"`
+	RoleARN    string           `json:"role_arn"`
+	AccountID  string           `json:"account_id"`
+	Policy     PolicyDocument   `json:"policy"`
+	Principals []TrustPrincipal `json:"principals"`
+	Issues     []TrustIssue     `json:"issues"`
+}
+
+// GetPrincipals returns the principals which can assume the role. (e.g. `AWS:arn:aws:iam::123456789012:root`)
+func (t RoleTrust) GetPrincipals() []string {
+	result := make([]string, len(t.Principals))
+	for i, p := range t.Principals {
+		result[i] = p.String()
+	}
+	return result
+}
+
+// GetIssues returns the issues of the trust policy. (e.g. `high: unknown-account (AWS:123456789012)`)
+func (t RoleTrust) GetIssues() []string {
+	result := make([]string, len(t.Issues))
+	for i, v := range t.Issues {
+		result[i] = v.String()
+	}
+	return result
+}
+
+// GetIdentities returns the subjects of OIDC providers which can assume the role. (e.g. `github:org/repo (ref:refs/heads/main)`)
+func (t RoleTrust) GetIdentities() []string {
+	var result []string
+	for _, p := range t.Principals {
+		for _, w := range p.Identities {
+			result = append(result, w.String())
+		}
+	}
+	return result
+}
+
+// getIssuesOf returns the issues of the principal.
+func (t RoleTrust) getIssuesOf(p TrustPrincipal) []TrustIssue {
+	var result []TrustIssue
+	for _, v := range t.Issues {
+		if v.Principal.Type == p.Type && v.Principal.Name == p.Name {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// TrustPrincipal is a principal which is allowed to assume the role.
+type TrustPrincipal struct {
+	Type       string        `json:"type"` // AWS, Service, Federated or CanonicalUser
+	Name       string        `json:"name"`
+	Actions    []string      `json:"actions"`
+	Conditions Condition     `json:"conditions,omitempty"`
+	Identities []WebIdentity `json:"identities,omitempty"` // subjects of OIDC provider
+
+	AccountID      string `json:"account_id,omitempty"`      // account of AWS principal
+	External       bool   `json:"external,omitempty"`        // AWS principal in the other account
+	UnknownAccount bool   `json:"unknown_account,omitempty"` // AWS principal in the other account which is not in the trusted accounts
+}
+
+func (p TrustPrincipal) String() string {
+	return p.Type + ":" + p.Name
+}
+
+// TrustIssue is a result of the trust rule matched to the principal.
+type TrustIssue struct {
+	Rule      TrustRule      `json:"rule"`
+	Principal TrustPrincipal `json:"principal"`
+}
+
+func (i TrustIssue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Rule.Severity.String(), i.Rule.Name, i.Principal.String())
+}
+
+// TrustRule is a risk rule for the principal in the trust policy.
+type TrustRule struct {
+	Name        string   `json:"name"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+
+	// Match checks if the principal is risky.
+	Match func(p TrustPrincipal) bool `json:"-"`
+}
+
+// DefaultTrustRules returns built-in rules for trust policy.
+func DefaultTrustRules() []TrustRule {
+	return []TrustRule{
+		{
+			Name:        "wildcard-principal",
+			Severity:    SeverityCritical,
+			Description: "allows any AWS principal to assume the role (`Principal: *`)",
+			Match: func(p TrustPrincipal) bool {
+				return p.Type == principalTypeAWS && hasWildcard(p.Name)
+			},
+		},
+		{
+			Name:        "unknown-account",
+			Severity:    SeverityHigh,
+			Description: "allows the external account which is not in the trusted accounts",
+			Match: func(p TrustPrincipal) bool {
+				return p.UnknownAccount
+			},
+		},
+		{
+			Name:        "cross-account-no-external-id",
+			Severity:    SeverityMedium,
+			Description: "allows the external account without `sts:ExternalId` condition",
+			Match: func(p TrustPrincipal) bool {
+				return p.External && !p.Conditions.HasKey("sts:ExternalId")
+			},
+		},
+		{
+			Name:        "federated-no-condition",
+			Severity:    SeverityHigh,
+			Description: "allows the federated users (SAML, Cognito, etc.) without conditions",
+			Match: func(p TrustPrincipal) bool {
+				return p.Type == principalTypeFederated && !isOIDCProvider(p.Name) && len(p.Conditions) == 0
+			},
+		},
+		{
+			Name:        "oidc-no-subject",
+			Severity:    SeverityHigh,
+			Description: "allows any subject of OIDC provider without `sub` condition",
+			Match: func(p TrustPrincipal) bool {
+				return isWebIdentityPrincipal(p) && !hasSubjectCondition(p)
+			},
+		},
+		{
+			Name:        "oidc-wildcard-subject",
+			Severity:    SeverityHigh,
+			Description: "allows subjects of OIDC provider by the wildcard (e.g. `repo:org/*`, `system:serviceaccount:*`)",
+			Match: func(p TrustPrincipal) bool {
+				return isWebIdentityPrincipal(p) && hasWildcardIdentity(p)
+			},
+		},
+		{
+			Name:        "oidc-no-audience",
+			Severity:    SeverityMedium,
+			Description: "allows tokens of OIDC provider without `aud` condition",
+			Match: func(p TrustPrincipal) bool {
+				return isWebIdentityPrincipal(p) && !hasAudienceCondition(p)
+			},
+		},
+	}
+}
+
+// isWebIdentityPrincipal checks if the principal is OIDC provider of IAM.
+func isWebIdentityPrincipal(p TrustPrincipal) bool {
+	return p.Type == principalTypeFederated && isOIDCProvider(p.Name)
+}
+
+// isOIDCProvider checks if the federated principal is OIDC provider of IAM.
+// (e.g. `arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com`)
+func isOIDCProvider(name string) bool {
+	return strings.Contains(name, ":oidc-provider/")
+}
+
+// newRoleTrust parses the trust policy of the role and applies the rules to the principals.
+// trustedAccounts are external account IDs known as trusted.
+func newRoleTrust(role iam.Role, trustedAccounts []string, rules []TrustRule) (*RoleTrust, error) {
+	t := &RoleTrust{
+		RoleName:  role.RoleName,
+		RoleARN:   role.ARN,
+		AccountID: getAccountID(role.ARN),
+	}
+	if role.AssumeRolePolicyDocument == "" {
+		return t, nil
+	}
+
+	policy, err := newPolicyDocumentFromRoleDocument(role.AssumeRolePolicyDocument)
+	if err != nil {
+		return nil, err
+	}
+	t.Policy = policy
+
+	trusted := make(map[string]bool, len(trustedAccounts)+1)
+	trusted[t.AccountID] = true
+	for _, v := range trustedAccounts {
+		trusted[v] = true
+	}
+
+	for _, s := range policy.Statement {
+		if !s.IsAllow() {
+			continue
+		}
+		for _, typ := range getSortedKeys(s.Principal) {
+			for _, name := range s.Principal[typ] {
+				p := TrustPrincipal{
+					Type:       typ,
+					Name:       name,
+					Actions:    s.Action,
+					Conditions: s.Condition,
+				}
+				switch {
+				case typ == principalTypeAWS:
+					p.AccountID = getAccountID(name)
+					p.External = p.AccountID != "" && p.AccountID != t.AccountID
+					p.UnknownAccount = p.AccountID != "" && !trusted[p.AccountID]
+				case isWebIdentityPrincipal(p):
+					p.Identities = getWebIdentities(p)
+				}
+				t.Principals = append(t.Principals, p)
+
+				for _, r := range rules {
+					if r.Match(p) {
+						t.Issues = append(t.Issues, TrustIssue{
+							Rule:      r,
+							Principal: p,
+						})
+					}
+				}
+			}
+		}
+	}
+	return t, nil
+}
+
+// newPolicyDocumentFromRoleDocument returns PolicyDocument from the trust policy.
+// The trust policy can be URL-encoded (from AWS API) or JSON.
+func newPolicyDocumentFromRoleDocument(document string) (PolicyDocument, error) {
+	if strings.HasPrefix(strings.TrimSpace(document), "{") {
+		return NewPolicyDocumentFromJSONString(document)
+	}
+	return NewPolicyDocumentFromDocument(document)
+}
+
+// getAccountID returns account ID from account ID, ARN or root ARN.
+// It returns empty string for others. (e.g. `*`, unique ID of the deleted principal)
+func getAccountID(v string) string {
+	if strings.HasPrefix(v, "arn:") {
+		parts := strings.Split(v, ":")
+		if len(parts) < 6 {
+			return ""
+		}
+		return parts[4]
+	}
+	if isAccountID(v) {
+		return v
+	}
+	return ""
+}
+
+// isAccountID checks if the value is 12 digits AWS account ID.
+func isAccountID(v string) bool {
+	if len(v) != 12 {
+		return false
+	}
+	for _, r := range v {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func getSortedKeys(m PrincipalValues) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+package checker
+
+import (
+	"sort"
+	"strings"
+)
+
+// OIDC provider types.
+const (
+	oidcProviderGitHub = "github"
+	oidcProviderEKS    = "eks"
+	oidcProviderOther  = "oidc"
+
+	oidcHostGitHub   = "token.actions.githubusercontent.com"
+	oidcHostEKS      = "oidc.eks."
+	eksSubjectPrefix = "system:serviceaccount:"
+)
+
+// WebIdentity is a subject of OIDC provider allowed by `sub` condition.
+// (e.g. GitHub Actions `repo:org/repo:ref:refs/heads/main`, EKS `system:serviceaccount:default:app`)
+type WebIdentity struct {
+	Provider string `json:"provider"` // github, eks or oidc
+	Subject  string `json:"subject"`
+	Wildcard bool   `json:"wildcard"` // repository, service account or subject is matched by the wildcard
+
+	// GitHub Actions
+	Repository string `json:"repository,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Context    string `json:"context,omitempty"` // e.g. `ref:refs/heads/main`, `environment:prod`, `pull_request`
+
+	// EKS
+	Namespace      string `json:"namespace,omitempty"`
+	ServiceAccount string `json:"service_account,omitempty"`
+}
+
+func (w WebIdentity) String() string {
+	switch {
+	case w.Provider == oidcProviderGitHub && w.Repository != "":
+		if w.Context == "" {
+			return "github:" + w.Repository
+		}
+		return "github:" + w.Repository + " (" + w.Context + ")"
+	case w.Provider == oidcProviderEKS && w.ServiceAccount != "":
+		return "eks:" + w.Namespace + "/" + w.ServiceAccount
+	}
+	return w.Provider + ":" + w.Subject
+}
+
+// newWebIdentity parses the subject of the OIDC provider.
+// isPattern is true when the subject is used in `StringLike` condition.
+func newWebIdentity(provider, subject string, isPattern bool) WebIdentity {
+	w := WebIdentity{
+		Provider: provider,
+		Subject:  subject,
+		Wildcard: isPattern && hasWildcard(subject),
+	}
+
+	switch provider {
+	case oidcProviderGitHub:
+		// repo:<org>/<repo>:<context>
+		if !strings.HasPrefix(subject, "repo:") {
+			return w
+		}
+		parts := strings.SplitN(strings.TrimPrefix(subject, "repo:"), ":", 2)
+		w.Repository = parts[0]
+		if len(parts) == 2 {
+			w.Context = parts[1]
+			if strings.HasPrefix(w.Context, "ref:refs/heads/") {
+				w.Branch = strings.TrimPrefix(w.Context, "ref:refs/heads/")
+			}
+		}
+		// the branch can be a wildcard, but the repository must be fixed.
+		w.Wildcard = isPattern && hasWildcard(w.Repository)
+	case oidcProviderEKS:
+		// system:serviceaccount:<namespace>:<service account>
+		if !strings.HasPrefix(subject, eksSubjectPrefix) {
+			return w
+		}
+		parts := strings.SplitN(strings.TrimPrefix(subject, eksSubjectPrefix), ":", 2)
+		w.Namespace = parts[0]
+		if len(parts) == 2 {
+			w.ServiceAccount = parts[1]
+		}
+	}
+	return w
+}
+
+// getOIDCProviderType returns provider type from the federated principal.
+func getOIDCProviderType(name string) string {
+	switch {
+	case strings.Contains(name, "/"+oidcHostGitHub):
+		return oidcProviderGitHub
+	case strings.Contains(name, "/"+oidcHostEKS):
+		return oidcProviderEKS
+	}
+	return oidcProviderOther
+}
+
+// getWebIdentities returns subjects allowed by `sub` condition of the OIDC provider.
+func getWebIdentities(p TrustPrincipal) []WebIdentity {
+	provider := getOIDCProviderType(p.Name)
+
+	var result []WebIdentity
+	for _, op := range getSortedConditionOperators(p.Conditions) {
+		isPattern := strings.Contains(strings.ToLower(op), "like")
+		for k, values := range p.Conditions[op] {
+			if !isSubjectConditionKey(k) {
+				continue
+			}
+			for _, v := range values {
+				result = append(result, newWebIdentity(provider, v, isPattern))
+			}
+		}
+	}
+	return result
+}
+
+// isSubjectConditionKey checks if the condition key is subject key of OIDC provider. (e.g. `token.actions.githubusercontent.com:sub`)
+func isSubjectConditionKey(key string) bool {
+	return strings.HasSuffix(strings.ToLower(key), ":sub")
+}
+
+// hasSubjectCondition checks if the conditions restrict the subject of OIDC provider.
+func hasSubjectCondition(p TrustPrincipal) bool {
+	for _, kv := range p.Conditions {
+		for k := range kv {
+			if isSubjectConditionKey(k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasAudienceCondition checks if the conditions restrict the audience of OIDC provider.
+func hasAudienceCondition(p TrustPrincipal) bool {
+	for _, kv := range p.Conditions {
+		for k := range kv {
+			if strings.HasSuffix(strings.ToLower(k), ":aud") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasWildcardIdentity checks if any of the subjects is matched by the wildcard.
+func hasWildcardIdentity(p TrustPrincipal) bool {
+	for _, w := range p.Identities {
+		if w.Wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+func getSortedConditionOperators(c Condition) []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package checker
+
+import (
+	"strings"
+)
+
+// matchAction checks if the action in the statement matches the target action.
+// Action name is case-insensitive.
+func matchAction(action, target string, useStatementWildcard bool) bool {
+	return matchPermission(strings.ToLower(action), strings.ToLower(toActionPattern(target)), useStatementWildcard)
+}
+
+// matchResource checks if the resource in the statement matches the target resource.
+// Resource ARN is case-sensitive.
+func matchResource(resource, target string, useStatementWildcard bool) bool {
+	return matchPermission(resource, target, useStatementWildcard)
+}
+
+// matchPermission checks if the value in the statement matches the target.
+//   - the target without wildcard matches as substring. (e.g. `Delete` matches `s3:DeleteBucket`)
+//   - the target with wildcard matches as glob pattern. (e.g. `s3:Get*` matches `s3:GetObject`)
+//   - when useStatementWildcard is true, the value with wildcard matches the target covered by the value. (e.g. `s3:*` matches `s3:GetObject`)
+func matchPermission(value, target string, useStatementWildcard bool) bool {
+	switch {
+	case !hasWildcard(target):
+		if strings.Contains(value, target) {
+			return true
+		}
+	case matchWildcard(target, value):
+		return true
+	}
+
+	return useStatementWildcard && hasWildcard(value) && matchWildcard(value, target)
+}
+
+// hasWildcard checks if the string contains wildcard character of IAM policy.
+func hasWildcard(s string) bool {
+	return strings.ContainsAny(s, "*?")
+}
+
+// matchWildcard checks if the text matches the pattern.
+// `*` matches any sequence of characters and `?` matches any single character.
+func matchWildcard(pattern, text string) bool {
+	p, t := 0, 0
+	starP, starT := -1, 0
+	for t < len(text) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == text[t]):
+			p++
+			t++
+		case p < len(pattern) && pattern[p] == '*':
+			starP = p
+			starT = t
+			p++
+		case starP != -1:
+			// backtrack to the last `*` and let it consume one more character.
+			p = starP + 1
+			starT++
+			t = starT
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// overlapWildcard checks if one of the patterns covers the other.
+func overlapWildcard(a, b string) bool {
+	return matchWildcard(a, b) || matchWildcard(b, a)
+}
+
+// toActionPattern converts the filtering rule of action into glob pattern.
+// The rule with service prefix and without wildcard matches the actions of the service starting with it. (e.g. `s3:Get` to `s3:Get*`)
+func toActionPattern(target string) string {
+	if strings.Contains(target, ":") && !hasWildcard(target) {
+		return target + "*"
+	}
+	return toWildcardPattern(target)
+}
+
+// toWildcardPattern converts the filtering rule into glob pattern.
+// The rule without wildcard is used as substring.
+func toWildcardPattern(target string) string {
+	if hasWildcard(target) {
+		return target
+	}
+	return "*" + target + "*"
+}
@@ -0,0 +1,177 @@
+package checker
+
+import (
+	"testing"
+)
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"*", "", true},
+		{"*", "s3:GetObject", true},
+		{"**", "s3:GetObject", true},
+		{"s3:GetObject", "s3:GetObject", true},
+		{"s3:GetObject", "s3:GetObjectAcl", false},
+		{"s3:Get*", "s3:GetObject", true},
+		{"s3:Get*", "s3:Get", true},
+		{"s3:Get*", "s3:PutObject", false},
+		{"s3:*Object", "s3:GetObject", true},
+		{"s3:*Object", "s3:GetObjectAcl", false},
+		{"s3:Get?bject", "s3:GetObject", true},
+		{"s3:Get?", "s3:GetObject", false},
+		{"s3:Get?", "s3:Get", false},
+		{"?", "a", true},
+		{"?", "", false},
+
+		// backtracking to the last `*`
+		{"*a*b", "xaybzb", true},
+		{"a*b*c", "abcbc", true},
+		{"a*b*c", "abcbd", false},
+		{"*Object*Acl", "s3:GetObjectVersionAcl", true},
+		{"a*b", "a", false},
+
+		// case-sensitive
+		{"S3:Get*", "s3:GetObject", false},
+		{"arn:aws:s3:::Prod-*", "arn:aws:s3:::prod-a", false},
+	}
+	for _, tt := range tests {
+		if got := matchWildcard(tt.pattern, tt.text); got != tt.want {
+			t.Errorf("matchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestOverlapWildcard(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want bool
+	}{
+		{"s3:*", "s3:Get*", true},
+		{"s3:Get*", "s3:*", true},
+		{"*", "s3:GetObject", true},
+		{"s3:GetObject", "s3:Get*", true},
+		{"s3:Get*", "s3:Put*", false},
+		{"s3:GetObject", "s3:GetObjectAcl", false},
+	}
+	for _, tt := range tests {
+		if got := overlapWildcard(tt.a, tt.b); got != tt.want {
+			t.Errorf("overlapWildcard(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAction(t *testing.T) {
+	tests := []struct {
+		name                 string
+		action               string
+		target               string
+		useStatementWildcard bool
+		want                 bool
+	}{
+		{"exact", "s3:GetObject", "s3:GetObject", false, true},
+		{"case-insensitive target", "s3:GetObject", "S3:getobject", false, true},
+		{"case-insensitive action", "S3:GETOBJECT", "s3:GetObject", false, true},
+		{"service-prefixed target is substring", "s3:DeleteBucketPolicy", "s3:DeleteBucket", false, true},
+		{"service-prefixed target matches itself", "s3:DeleteBucket", "s3:DeleteBucket", false, true},
+		{"service-prefixed partial name", "s3:GetObject", "s3:Get", false, true},
+		{"service-prefixed target of other service", "sqs:GetQueueUrl", "s3:Get", false, false},
+		{"service prefix is whole name", "rds:DeleteDBInstance", "ds:Delete", false, false},
+		{"target without prefix is substring", "s3:DeleteBucketPolicy", "DeleteBucket", false, true},
+		{"substring is case-insensitive", "s3:DeleteBucket", "delete", false, true},
+		{"substring does not match", "s3:GetObject", "Delete", false, false},
+		{"pattern target", "s3:GetObject", "s3:Get*", false, true},
+		{"pattern target does not match", "s3:PutObject", "s3:Get*", false, false},
+		{"pattern target for any service", "sqs:GetQueueUrl", "*:Get*", false, true},
+		{"pattern target covers statement pattern", "s3:Get*", "s3:*", false, true},
+
+		// wildcard in the statement
+		{"statement wildcard without flag", "s3:*", "s3:GetObject", false, false},
+		{"statement wildcard covers target", "s3:*", "s3:GetObject", true, true},
+		{"statement star covers target", "*", "s3:GetObject", true, true},
+		{"statement pattern covers target", "s3:Get*", "s3:GetObject", true, true},
+		{"statement pattern does not cover target", "s3:Get*", "s3:PutObject", true, false},
+		{"statement wildcard of other service", "sqs:*", "s3:GetObject", true, false},
+		{"statement wildcard is case-insensitive", "S3:get*", "s3:GetObject", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchAction(tt.action, tt.target, tt.useStatementWildcard); got != tt.want {
+				t.Errorf("matchAction(%q, %q, %v) = %v, want %v", tt.action, tt.target, tt.useStatementWildcard, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	tests := []struct {
+		name                 string
+		resource             string
+		target               string
+		useStatementWildcard bool
+		want                 bool
+	}{
+		{"exact", "arn:aws:s3:::prod-a", "arn:aws:s3:::prod-a", false, true},
+		{"substring", "arn:aws:s3:::prod-a/*", "prod-", false, true},
+		{"substring does not match", "arn:aws:s3:::dev-a/*", "prod-", false, false},
+		{"case-sensitive", "arn:aws:s3:::Prod-a", "prod-", false, false},
+		{"case-sensitive pattern", "arn:aws:s3:::Prod-a", "arn:aws:s3:::prod-*", false, false},
+		{"pattern", "arn:aws:s3:::prod-a/key", "arn:aws:s3:::prod-*", false, true},
+		{"pattern with ?", "arn:aws:s3:::prod-a", "arn:aws:s3:::prod-?", false, true},
+		{"pattern does not match", "arn:aws:s3:::dev-a", "arn:aws:s3:::prod-*", false, false},
+
+		// wildcard in the statement
+		{"statement star without flag", "*", "arn:aws:s3:::prod-a", false, false},
+		{"statement star with flag", "*", "arn:aws:s3:::prod-a", true, true},
+		{"statement pattern covers target", "arn:aws:s3:::prod-*", "arn:aws:s3:::prod-a/key", true, true},
+		{"statement pattern does not cover target", "arn:aws:s3:::dev-*", "arn:aws:s3:::prod-a", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchResource(tt.resource, tt.target, tt.useStatementWildcard); got != tt.want {
+				t.Errorf("matchResource(%q, %q, %v) = %v, want %v", tt.resource, tt.target, tt.useStatementWildcard, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToActionPattern(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"s3:GetObject", "s3:GetObject*"},
+		{"s3:Get*", "s3:Get*"},
+		{"Delete", "*Delete*"},
+		{"Get*", "Get*"},
+		{"*", "*"},
+	}
+	for _, tt := range tests {
+		if got := toActionPattern(tt.target); got != tt.want {
+			t.Errorf("toActionPattern(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestToWildcardPattern(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"s3:GetObject", "*s3:GetObject*"},
+		{"s3:Get*", "s3:Get*"},
+		{"Delete", "*Delete*"},
+		{"Get?", "Get?"},
+		{"*", "*"},
+	}
+	for _, tt := range tests {
+		if got := toWildcardPattern(tt.target); got != tt.want {
+			t.Errorf("toWildcardPattern(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mkideal/cli"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// catalog command
+type catalogT struct {
+	cli.Helper
+	Output  string `cli:"o,output" usage:"output JSON file path of the action catalog (e.g. --output='./action_catalog.json')" dft:"action_catalog.json"`
+	Input   string `cli:"*i,input" usage:"JSON files of Service Authorization Reference or the action catalog; space separated (e.g. --input='./s3.json ./iam.json')"`
+	Catalog string `cli:"catalog" usage:"JSON file of the action catalog to be refreshed; use this instead of the built-in catalog (e.g. --catalog='./action_catalog.json')"`
+}
+
+var catalog = &cli.Command{
+	Name: "catalog",
+	Desc: "Refresh the action catalog from local files",
+	Argv: func() interface{} { return new(catalogT) },
+	Fn:   execCatalog,
+}
+
+func execCatalog(ctx *cli.Context) error {
+	argv := ctx.Argv().(*catalogT)
+
+	inputs := strings.Fields(argv.Input)
+	if len(inputs) == 0 {
+		return errors.New("--input is empty")
+	}
+
+	c, err := checker.RefreshActionCatalog(argv.Catalog, argv.Output, inputs...)
+	if err != nil {
+		return err
+	}
+
+	ctx.String(fmt.Sprintf("saved %d actions of %d services to '%s'\n", c.Size(), len(c.Services), argv.Output))
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mkideal/cli"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// check command
+type checkT struct {
+	cli.Helper
+	Config      string `cli:"*c,config" usage:"YAML config file of named checks (e.g. --config='./checks.yaml')"`
+	Name        string `cli:"n,name" usage:"run only the checks of the names; space separated (e.g. --name='s3-writers kms-admins')"`
+	Input       string `cli:"i,input" usage:"JSON file of 'aws iam get-account-authorization-details'; use this instead of 'input' in the config file (e.g. --input='./details.json')"`
+	Parallel    int    `cli:"p,parallel" usage:"number of concurrent API calls; use this instead of 'parallel' in the config file"`
+	MaxRetry    int    `cli:"max-retry" usage:"max retry count on API throttling error; use this instead of 'max_retry' in the config file"`
+	Suppression string `cli:"suppression" usage:"YAML file of the accepted exceptions; use this instead of 'suppression' in the config file"`
+	Strict      bool   `cli:"strict" usage:"exit with code 3 when any item is skipped by errors; use this instead of 'strict' in the config file"`
+	FailOnMatch bool   `cli:"fail-on-match" usage:"exit with code 2 when any result is found in any check; use this instead of 'fail_on_match' in the config file"`
+	FailOn      string `cli:"fail-on" usage:"exit with code 2 when any finding of audit, trust and lint checks is at or above the severity; use this instead of 'fail_on' in the config file"`
+	Quiet       bool   `cli:"q,quiet" usage:"show only error logs"`
+	Verbose     bool   `cli:"v,verbose" usage:"show debug logs including each API call"`
+	LogFormat   string `cli:"log-format" usage:"log format (text, json); logs are written into stderr"`
+}
+
+var check = &cli.Command{
+	Name: "check",
+	Desc: "Run the named checks in the config file from a single data fetch",
+	Argv: func() interface{} { return new(checkT) },
+	Fn:   execCheck,
+}
+
+func execCheck(ctx *cli.Context) error {
+	argv := ctx.Argv().(*checkT)
+
+	logLevel, err := getLogLevel(argv.Quiet, argv.Verbose)
+	if err != nil {
+		return err
+	}
+
+	f, err := checker.ReadCheckFile(argv.Config)
+	if err != nil {
+		return err
+	}
+	if argv.Input != "" {
+		f.Input = argv.Input
+	}
+	if argv.Parallel != 0 {
+		f.Parallel = argv.Parallel
+	}
+	if argv.MaxRetry != 0 {
+		f.MaxRetry = argv.MaxRetry
+	}
+	if argv.Suppression != "" {
+		f.Suppression = argv.Suppression
+	}
+	if argv.Strict {
+		f.Strict = true
+	}
+	if argv.FailOnMatch {
+		f.FailOnMatch = true
+	}
+	if argv.FailOn != "" {
+		f.FailOn = argv.FailOn
+	}
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	if logLevel != "" {
+		f.LogLevel = logLevel
+	}
+	if argv.LogFormat != "" {
+		f.LogFormat = argv.LogFormat
+	}
+
+	checks, err := f.Select(strings.Fields(argv.Name))
+	if err != nil {
+		return err
+	}
+	summaries, err := checks.Run()
+	for i := range summaries {
+		printSummary(&summaries[i])
+	}
+	return err
+}
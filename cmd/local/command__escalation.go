@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/mkideal/cli"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// escalation command
+type escalationT struct {
+	cli.Helper
+	Output           string `cli:"o,output" usage:"output CSV/TSV/JSON file path (e.g. --output='./output.csv')" dft:"escalation.csv"`
+	Format           string `cli:"f,format" usage:"output format (csv, tsv, json, ndjson); decided by the file extension when empty"`
+	ErrorOutput      string `cli:"error-output" usage:"output file of the items skipped by errors (default: <output>.errors.<ext>)"`
+	SuppressedOutput string `cli:"suppressed-output" usage:"output file of the results suppressed by --suppression (default: <output>.suppressed.<ext>)"`
+	Input            string `cli:"i,input" usage:"JSON file of 'aws iam get-account-authorization-details'; use this instead of AWS API (e.g. --input='./details.json')"`
+	Parallel         int    `cli:"p,parallel" usage:"number of concurrent API calls" dft:"1"`
+	MaxRetry         int    `cli:"max-retry" usage:"max retry count on API throttling error" dft:"5"`
+	ExcludeEntity    string `cli:"exclude-entity" usage:"exclusion rule for User/Group/Role names; space separated (e.g. --exclude-entity='role/AWSServiceRoleFor* user/admin')"`
+	ExcludePolicy    string `cli:"exclude-policy" usage:"exclusion rule for policy names or ARNs; space separated (e.g. --exclude-policy='AWSServiceRole*')"`
+	Suppression      string `cli:"suppression" usage:"YAML file of the accepted exceptions; the results are moved into the suppressed output file until the expiry date (e.g. --suppression='./suppressions.yaml')"`
+	Strict           bool   `cli:"strict" usage:"exit with code 3 when any item is skipped by errors (e.g. AccessDenied on GetPolicyVersion)"`
+	FailOnMatch      bool   `cli:"fail-on-match" usage:"exit with code 2 when any result is found"`
+	Quiet            bool   `cli:"q,quiet" usage:"show only error logs"`
+	Verbose          bool   `cli:"v,verbose" usage:"show debug logs including each API call"`
+	LogFormat        string `cli:"log-format" usage:"log format (text, json); logs are written into stderr"`
+}
+
+var escalation = &cli.Command{
+	Name: "escalation",
+	Desc: "Get list of privilege escalation paths of User/Role",
+	Argv: func() interface{} { return new(escalationT) },
+	Fn:   execEscalation,
+}
+
+func execEscalation(ctx *cli.Context) error {
+	argv := ctx.Argv().(*escalationT)
+
+	logLevel, err := getLogLevel(argv.Quiet, argv.Verbose)
+	if err != nil {
+		return err
+	}
+
+	c, err := checker.NewWithConfig(checker.Config{
+		OutputFile:           argv.Output,
+		OutputFormat:         argv.Format,
+		ErrorOutputFile:      argv.ErrorOutput,
+		SuppressedOutputFile: argv.SuppressedOutput,
+		InputFile:            argv.Input,
+		Parallel:             argv.Parallel,
+		MaxRetry:             argv.MaxRetry,
+		ShowAllPolicy:        true,
+		ExcludeEntity:        argv.ExcludeEntity,
+		ExcludePolicy:        argv.ExcludePolicy,
+		SuppressionFile:      argv.Suppression,
+		Strict:               argv.Strict,
+		FailOnMatch:          argv.FailOnMatch,
+		LogLevel:             logLevel,
+		LogFormat:            argv.LogFormat,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.CheckEscalation()
+	printSummary(c.Summary())
+	return err
+}
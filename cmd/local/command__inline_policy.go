@@ -9,11 +9,35 @@ import (
 // inlinePolicy command
 type inlinePolicyT struct {
 	cli.Helper
-	Output              string `cli:"o,output" usage:"output CSV/TSV file path (e.g. --output='./output.csv')" dft:"inline_policy.csv"`
+	Output              string `cli:"o,output" usage:"output CSV/TSV/JSON file path (e.g. --output='./output.csv')" dft:"inline_policy.csv"`
+	Format              string `cli:"f,format" usage:"output format (csv, tsv, json, ndjson); decided by the file extension when empty"`
+	ErrorOutput         string `cli:"error-output" usage:"output file of the items skipped by errors (default: <output>.errors.<ext>)"`
+	SuppressedOutput    string `cli:"suppressed-output" usage:"output file of the results suppressed by --suppression (default: <output>.suppressed.<ext>)"`
+	Input               string `cli:"i,input" usage:"JSON file of 'aws iam get-account-authorization-details'; use this instead of AWS API (e.g. --input='./details.json')"`
+	Parallel            int    `cli:"p,parallel" usage:"number of concurrent API calls" dft:"1"`
+	MaxRetry            int    `cli:"max-retry" usage:"max retry count on API throttling error" dft:"5"`
 	TargetResource      string `cli:"r,resource" usage:"filtering rule for resources; space separated (e.g. --resource='arn:aws:s3:* arn:aws:sns:*')"`
 	TargetAction        string `cli:"a,action" usage:"filtering rule for action; space separated (e.g. --action='S3:Get* SNS:*')"`
 	TargetActionService string `cli:"s,service" usage:"filtering rule for action services; space separated (e.g. --service='s3 sns ecr')"`
+	AccessLevel         string `cli:"access-level" usage:"filtering rule for access levels of actions (list, read, write, permissions-management, tagging); comma separated (e.g. --access-level=write,permissions-management)"`
+	Filter              string `cli:"filter" usage:"filter expression evaluated per statement; combine 'service:', 'action:', 'resource:', 'access:' and 'condition:' with AND/OR/NOT (e.g. --filter='service:s3 AND action:Put* AND resource:arn:aws:s3:::prod-*')"`
+	Catalog             string `cli:"catalog" usage:"JSON file of the action catalog; use this instead of the built-in catalog (e.g. --catalog='./action_catalog.json')"`
+	CatalogStrict       bool   `cli:"catalog-strict" usage:"reject unknown actions of the services in the action catalog in --action and --filter; they are warnings by default"`
 	AllPolicy           bool   `cli:"all" usage:"do not use filtering and output all inline policy"`
+	StatementWildcard   bool   `cli:"w,wildcard" usage:"match when the wildcard in the statement covers the target (e.g. 's3:*' covers --action='s3:GetObject')"`
+	Unconditional       bool   `cli:"unconditional" usage:"match only statements without Condition"`
+	MissingCondition    string `cli:"missing-condition" usage:"match only statements without the condition key; space separated (e.g. --missing-condition='aws:MultiFactorAuthPresent')"`
+	TrustedAccount      string `cli:"trusted-account" usage:"external account IDs allowed in trust policies of the roles; space separated (e.g. --trusted-account='123456789012 210987654321')"`
+	ExcludeResource     string `cli:"exclude-resource" usage:"exclusion rule for resources; space separated (e.g. --exclude-resource='arn:aws:s3:::logs-*')"`
+	ExcludeAction       string `cli:"exclude-action" usage:"exclusion rule for action; space separated (e.g. --exclude-action='*:Describe* ec2:Get*')"`
+	ExcludeEntity       string `cli:"exclude-entity" usage:"exclusion rule for User/Group/Role names; space separated (e.g. --exclude-entity='role/AWSServiceRoleFor* user/admin')"`
+	ExcludePolicy       string `cli:"exclude-policy" usage:"exclusion rule for policy names or ARNs; space separated (e.g. --exclude-policy='AWSServiceRole*')"`
+	Suppression         string `cli:"suppression" usage:"YAML file of the accepted exceptions; the results are moved into the suppressed output file until the expiry date (e.g. --suppression='./suppressions.yaml')"`
+	Strict              bool   `cli:"strict" usage:"exit with code 3 when any item is skipped by errors (e.g. AccessDenied on GetPolicyVersion)"`
+	FailOnMatch         bool   `cli:"fail-on-match" usage:"exit with code 2 when any result is found"`
+	Quiet               bool   `cli:"q,quiet" usage:"show only error logs"`
+	Verbose             bool   `cli:"v,verbose" usage:"show debug logs including each API call"`
+	LogFormat           string `cli:"log-format" usage:"log format (text, json); logs are written into stderr"`
 }
 
 var inlinePolicy = &cli.Command{
@@ -26,16 +50,46 @@ var inlinePolicy = &cli.Command{
 func execInlinePolicy(ctx *cli.Context) error {
 	argv := ctx.Argv().(*inlinePolicyT)
 
+	logLevel, err := getLogLevel(argv.Quiet, argv.Verbose)
+	if err != nil {
+		return err
+	}
+
 	c, err := checker.NewWithConfig(checker.Config{
-		OutputFile:          argv.Output,
-		TargetResource:      argv.TargetResource,
-		TargetAction:        argv.TargetAction,
-		TargetActionService: argv.TargetActionService,
-		ShowAllPolicy:       argv.AllPolicy,
+		OutputFile:             argv.Output,
+		OutputFormat:           argv.Format,
+		ErrorOutputFile:        argv.ErrorOutput,
+		SuppressedOutputFile:   argv.SuppressedOutput,
+		InputFile:              argv.Input,
+		Parallel:               argv.Parallel,
+		MaxRetry:               argv.MaxRetry,
+		TargetResource:         argv.TargetResource,
+		TargetAction:           argv.TargetAction,
+		TargetActionService:    argv.TargetActionService,
+		AccessLevel:            argv.AccessLevel,
+		Filter:                 argv.Filter,
+		ActionCatalogFile:      argv.Catalog,
+		CatalogStrict:          argv.CatalogStrict,
+		ShowAllPolicy:          argv.AllPolicy,
+		MatchStatementWildcard: argv.StatementWildcard,
+		OnlyUnconditional:      argv.Unconditional,
+		MissingCondition:       argv.MissingCondition,
+		TrustedAccount:         argv.TrustedAccount,
+		ExcludeResource:        argv.ExcludeResource,
+		ExcludeAction:          argv.ExcludeAction,
+		ExcludeEntity:          argv.ExcludeEntity,
+		ExcludePolicy:          argv.ExcludePolicy,
+		SuppressionFile:        argv.Suppression,
+		Strict:                 argv.Strict,
+		FailOnMatch:            argv.FailOnMatch,
+		LogLevel:               logLevel,
+		LogFormat:              argv.LogFormat,
 	})
 	if err != nil {
 		return err
 	}
 
-	return c.CheckInlinePolicies()
+	err = c.CheckInlinePolicies()
+	printSummary(c.Summary())
+	return err
 }
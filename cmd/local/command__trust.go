@@ -0,0 +1,68 @@
+package main
+
+import (
+	"github.com/mkideal/cli"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// trust command
+type trustT struct {
+	cli.Helper
+	Output           string `cli:"o,output" usage:"output CSV/TSV/JSON file path (e.g. --output='./output.csv')" dft:"trust.csv"`
+	Format           string `cli:"f,format" usage:"output format (csv, tsv, json, ndjson); decided by the file extension when empty"`
+	ErrorOutput      string `cli:"error-output" usage:"output file of the items skipped by errors (default: <output>.errors.<ext>)"`
+	SuppressedOutput string `cli:"suppressed-output" usage:"output file of the results suppressed by --suppression (default: <output>.suppressed.<ext>)"`
+	Input            string `cli:"i,input" usage:"JSON file of 'aws iam get-account-authorization-details'; use this instead of AWS API (e.g. --input='./details.json')"`
+	MaxRetry         int    `cli:"max-retry" usage:"max retry count on API throttling error" dft:"5"`
+	TrustedAccount   string `cli:"trusted-account" usage:"external account IDs allowed in trust policies; space separated (e.g. --trusted-account='123456789012 210987654321')"`
+	ExcludeEntity    string `cli:"exclude-entity" usage:"exclusion rule for role names; space separated (e.g. --exclude-entity='AWSServiceRoleFor*')"`
+	Suppression      string `cli:"suppression" usage:"YAML file of the accepted exceptions; the results are moved into the suppressed output file until the expiry date (e.g. --suppression='./suppressions.yaml')"`
+	Strict           bool   `cli:"strict" usage:"exit with code 3 when any item is skipped by errors (e.g. AccessDenied on GetPolicyVersion)"`
+	FailOnMatch      bool   `cli:"fail-on-match" usage:"exit with code 2 when any issue is found in the trust policies"`
+	FailOn           string `cli:"fail-on" usage:"exit with code 2 when any finding is at or above the severity (critical, high, medium, low)"`
+	Quiet            bool   `cli:"q,quiet" usage:"show only error logs"`
+	Verbose          bool   `cli:"v,verbose" usage:"show debug logs including each API call"`
+	LogFormat        string `cli:"log-format" usage:"log format (text, json); logs are written into stderr"`
+}
+
+var trust = &cli.Command{
+	Name: "trust",
+	Desc: "Get list of principals which can assume the roles from trust policies",
+	Argv: func() interface{} { return new(trustT) },
+	Fn:   execTrust,
+}
+
+func execTrust(ctx *cli.Context) error {
+	argv := ctx.Argv().(*trustT)
+
+	logLevel, err := getLogLevel(argv.Quiet, argv.Verbose)
+	if err != nil {
+		return err
+	}
+
+	c, err := checker.NewWithConfig(checker.Config{
+		OutputFile:           argv.Output,
+		OutputFormat:         argv.Format,
+		ErrorOutputFile:      argv.ErrorOutput,
+		SuppressedOutputFile: argv.SuppressedOutput,
+		InputFile:            argv.Input,
+		MaxRetry:             argv.MaxRetry,
+		TrustedAccount:       argv.TrustedAccount,
+		ShowAllPolicy:        true,
+		ExcludeEntity:        argv.ExcludeEntity,
+		SuppressionFile:      argv.Suppression,
+		Strict:               argv.Strict,
+		FailOnMatch:          argv.FailOnMatch,
+		FailOn:               argv.FailOn,
+		LogLevel:             logLevel,
+		LogFormat:            argv.LogFormat,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.CheckTrust()
+	printSummary(c.Summary())
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// exit codes for CI.
+const (
+	exitCodeOK          = 0
+	exitCodeError       = 1 // invalid options, API errors, etc.
+	exitCodeFindings    = 2 // findings exceed --fail-on-match or --fail-on
+	exitCodePartialData = 3 // items are skipped by errors in --strict mode
+)
+
+// getExitCode returns the exit code from the error of the command.
+// When the findings and the skipped items exist together, exitCodeFindings is used.
+func getExitCode(err error) int {
+	var findingsErr *checker.FindingsError
+	var partialErr *checker.PartialDataError
+	switch {
+	case err == nil:
+		return exitCodeOK
+	case errors.As(err, &findingsErr):
+		return exitCodeFindings
+	case errors.As(err, &partialErr):
+		return exitCodePartialData
+	}
+	return exitCodeError
+}
+
+// printSummary prints one-line summary of the check into stdout for CI logs.
+func printSummary(s *checker.CheckSummary) {
+	if s != nil {
+		fmt.Println(s.String())
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+func TestGetExitCode(t *testing.T) {
+	findingsErr := &checker.FindingsError{Summaries: []checker.CheckSummary{{Name: "audit", Findings: 1}}}
+	partialErr := &checker.PartialDataError{Errors: []checker.FetchError{{Func: "GetGroup", Error: "AccessDenied"}}}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"no error", nil, exitCodeOK},
+		{"findings", findingsErr, exitCodeFindings},
+		{"wrapped findings", fmt.Errorf("check 'audit': %w", findingsErr), exitCodeFindings},
+		{"skipped items", partialErr, exitCodePartialData},
+		{"wrapped skipped items", fmt.Errorf("check 'policy': %w", partialErr), exitCodePartialData},
+		{"other error", errors.New("Config does not contain valid rules"), exitCodeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getExitCode(tt.err); got != tt.want {
+				t.Errorf("getExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/evalphobia/cloud-iam-policy-checker/checker"
+)
+
+// getLogLevel returns the log level from --quiet and --verbose.
+func getLogLevel(quiet, verbose bool) (string, error) {
+	switch {
+	case quiet && verbose:
+		return "", errors.New("--quiet and --verbose cannot be used together")
+	case quiet:
+		return checker.LogLevelError, nil
+	case verbose:
+		return checker.LogLevelDebug, nil
+	}
+	return "", nil
+}
@@ -12,9 +12,16 @@ func main() {
 		cli.Tree(help),
 		cli.Tree(policy),
 		cli.Tree(inlinePolicy),
+		cli.Tree(audit),
+		cli.Tree(escalation),
+		cli.Tree(trust),
+		cli.Tree(principal),
+		cli.Tree(check),
+		cli.Tree(lint),
+		cli.Tree(catalog),
 	).Run(os.Args[1:]); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(getExitCode(err))
 	}
 }
 